@@ -0,0 +1,218 @@
+package blockfile
+
+import (
+	"errors"
+	"fmt"
+	"io"
+	"math/big"
+	"os"
+	"path/filepath"
+	"sort"
+
+	"github.com/balletcrypto/bitcoin-inscription-parser/parser"
+	"github.com/btcsuite/btcd/blockchain"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// ErrGenesisNotFound is returned when the block files do not hold the genesis block, e.g. on a pruned node, so
+// heights can not be assigned
+var ErrGenesisNotFound = errors.New("genesis block not found in block files")
+
+// BlockLocation is where a block is stored in the block files.
+type BlockLocation struct {
+	Hash   chainhash.Hash
+	Header wire.BlockHeader
+	// Height is only set for the blocks returned by Dir.Index
+	Height int32
+	// File is the number of the blk*.dat file
+	File int
+	// Offset is the offset of the block data in the file, after the record prefix
+	Offset int64
+	Size   uint32
+}
+
+// Dir is the blocks directory of a Bitcoin Core data directory.
+type Dir struct {
+	path   string
+	params *chaincfg.Params
+	key    []byte
+	// files holds the number of each blk*.dat file, in numeric order
+	files []int
+}
+
+// OpenDir lists the block files of blocksDir, normally <datadir>/blocks, and reads its obfuscation key.
+func OpenDir(blocksDir string, params *chaincfg.Params) (*Dir, error) {
+	key, err := ReadXORKey(blocksDir)
+	if err != nil {
+		return nil, err
+	}
+	paths, err := filepath.Glob(filepath.Join(blocksDir, "blk*.dat"))
+	if err != nil {
+		return nil, err
+	}
+	dir := &Dir{
+		path:   blocksDir,
+		params: params,
+		key:    key,
+	}
+	for _, path := range paths {
+		var file int
+		if _, err := fmt.Sscanf(filepath.Base(path), "blk%05d.dat", &file); err != nil {
+			continue
+		}
+		dir.files = append(dir.files, file)
+	}
+	sort.Ints(dir.files)
+	return dir, nil
+}
+
+// Index scans the headers of every block file and returns the best chain ordered by height, so that the block at
+// height h is at index h. Blocks are chained by their previous block hash from the genesis block, and the tip with
+// the most cumulative work wins, the first one found on a tie. Blocks off the best chain are left out.
+func (d *Dir) Index() ([]*BlockLocation, error) {
+	var (
+		locations = make(map[chainhash.Hash]*BlockLocation)
+		children  = make(map[chainhash.Hash][]*BlockLocation)
+	)
+	for _, file := range d.files {
+		err := d.readFile(file, func(reader *Reader) error {
+			for {
+				header, offset, size, err := reader.NextHeader()
+				if err == io.EOF {
+					return nil
+				} else if err != nil {
+					return err
+				}
+				location := &BlockLocation{
+					Hash:   header.BlockHash(),
+					Header: *header,
+					File:   file,
+					Offset: offset,
+					Size:   size,
+				}
+				// A block can be stored twice after a crash, the first copy is kept
+				if _, ok := locations[location.Hash]; ok {
+					continue
+				}
+				locations[location.Hash] = location
+				children[header.PrevBlock] = append(children[header.PrevBlock], location)
+			}
+		})
+		if err != nil {
+			return nil, err
+		}
+	}
+
+	genesis, ok := locations[*d.params.GenesisHash]
+	if !ok {
+		return nil, ErrGenesisNotFound
+	}
+	// Walk down from the genesis block, blocks that do not connect to it are never reached
+	work := map[chainhash.Hash]*big.Int{genesis.Hash: blockchain.CalcWork(genesis.Header.Bits)}
+	best := genesis
+	queue := []*BlockLocation{genesis}
+	for len(queue) > 0 {
+		location := queue[0]
+		queue = queue[1:]
+		for _, child := range children[location.Hash] {
+			child.Height = location.Height + 1
+			work[child.Hash] = new(big.Int).Add(work[location.Hash], blockchain.CalcWork(child.Header.Bits))
+			if work[child.Hash].Cmp(work[best.Hash]) > 0 {
+				best = child
+			}
+			queue = append(queue, child)
+		}
+	}
+
+	chain := make([]*BlockLocation, best.Height+1)
+	for location := best; ; location = locations[location.Header.PrevBlock] {
+		chain[location.Height] = location
+		if location.Height == 0 {
+			break
+		}
+	}
+	return chain, nil
+}
+
+// ReadBlock reads the block stored at location.
+func (d *Dir) ReadBlock(location *BlockLocation) (*wire.MsgBlock, error) {
+	var msgBlock *wire.MsgBlock
+	err := d.openFile(location.File, func(f *os.File) error {
+		var err error
+		msgBlock, err = d.readBlockAt(f, location)
+		return err
+	})
+	return msgBlock, err
+}
+
+// ParseInscriptions reads the blocks of chain in order, as returned by Index, and calls fn with the inscriptions
+// of every block. It stops at the first error returned by fn.
+func (d *Dir) ParseInscriptions(chain []*BlockLocation, fn func(*parser.BlockInscriptions) error) error {
+	var (
+		f    *os.File
+		file = -1
+	)
+	defer func() {
+		if f != nil {
+			_ = f.Close()
+		}
+	}()
+
+	for _, location := range chain {
+		// Consecutive blocks are mostly in the same file, which is kept open
+		if location.File != file {
+			if f != nil {
+				_ = f.Close()
+			}
+			var err error
+			if f, err = os.Open(d.filePath(location.File)); err != nil {
+				return err
+			}
+			file = location.File
+		}
+		msgBlock, err := d.readBlockAt(f, location)
+		if err != nil {
+			return err
+		}
+		if err := fn(parser.ParseInscriptionsFromBlock(msgBlock, location.Height)); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+func (d *Dir) readBlockAt(f *os.File, location *BlockLocation) (*wire.MsgBlock, error) {
+	if _, err := f.Seek(location.Offset, io.SeekStart); err != nil {
+		return nil, err
+	}
+	msgBlock := &wire.MsgBlock{}
+	reader := io.LimitReader(newXORReader(f, d.key, location.Offset), int64(location.Size))
+	if err := msgBlock.Deserialize(reader); err != nil {
+		return nil, fmt.Errorf("block %s in %s: %w", location.Hash, d.filePath(location.File), err)
+	}
+	return msgBlock, nil
+}
+
+func (d *Dir) readFile(file int, fn func(*Reader) error) error {
+	return d.openFile(file, func(f *os.File) error {
+		if err := fn(NewReader(f, d.params.Net, d.key)); err != nil {
+			return fmt.Errorf("%s: %w", d.filePath(file), err)
+		}
+		return nil
+	})
+}
+
+func (d *Dir) openFile(file int, fn func(*os.File) error) error {
+	f, err := os.Open(d.filePath(file))
+	if err != nil {
+		return err
+	}
+	defer f.Close()
+	return fn(f)
+}
+
+func (d *Dir) filePath(file int) string {
+	return filepath.Join(d.path, fmt.Sprintf("blk%05d.dat", file))
+}
@@ -0,0 +1,187 @@
+// Package blockfile reads the blk*.dat files of a Bitcoin Core data directory, so that inscriptions can be
+// backfilled without RPC.
+//
+// A block file is a sequence of records: the 4-byte network magic, the little-endian size of the block, then the
+// serialized block. Since Bitcoin Core 28.0 the files may be obfuscated by XOR with the 8-byte key stored in
+// blocks/xor.dat, applied from the start of each file. Blocks are written in the order they were downloaded, which
+// is not height order, see Dir.Index to order them.
+package blockfile
+
+import (
+	"bufio"
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+
+	"github.com/btcsuite/btcd/wire"
+)
+
+// XORKeyFile is the name of the obfuscation key file in the blocks directory
+const XORKeyFile = "xor.dat"
+
+var (
+	// ErrInvalidMagic is returned when a record does not start with the magic bytes of the expected network
+	ErrInvalidMagic = errors.New("invalid network magic")
+	// ErrBlockTooLarge is returned when a record claims a size larger than any valid block
+	ErrBlockTooLarge = errors.New("block size is larger than the maximum block payload")
+	// ErrInvalidXORKey is returned when xor.dat does not hold an 8-byte key
+	ErrInvalidXORKey = errors.New("invalid xor key")
+)
+
+// recordHeaderSize is the size of the magic and size prefix of each record
+const recordHeaderSize = 8
+
+// ReadXORKey reads the obfuscation key of the blocks directory. A missing xor.dat, as written by Bitcoin Core before
+// 28.0, means the files are not obfuscated and a nil key is returned.
+func ReadXORKey(blocksDir string) ([]byte, error) {
+	key, err := os.ReadFile(filepath.Join(blocksDir, XORKeyFile))
+	if errors.Is(err, os.ErrNotExist) {
+		return nil, nil
+	} else if err != nil {
+		return nil, err
+	}
+	if len(key) != 8 {
+		return nil, fmt.Errorf("%w: %d bytes", ErrInvalidXORKey, len(key))
+	}
+	return key, nil
+}
+
+// Reader reads the blocks of a single block file in file order.
+type Reader struct {
+	r   *xorReader
+	net wire.BitcoinNet
+}
+
+// NewReader returns a reader of the blocks of net stored in r, which must be positioned at the start of the file.
+// key is the obfuscation key, nil if the file is not obfuscated. Skipping block data seeks when r is an io.Seeker.
+func NewReader(r io.Reader, net wire.BitcoinNet, key []byte) *Reader {
+	return &Reader{
+		r:   newXORReader(r, key, 0),
+		net: net,
+	}
+}
+
+// Next returns the next block and the offset of its data in the file, and io.EOF after the last block.
+func (r *Reader) Next() (*wire.MsgBlock, int64, error) {
+	offset, size, err := r.nextRecord()
+	if err != nil {
+		return nil, 0, err
+	}
+	msgBlock := &wire.MsgBlock{}
+	if err := msgBlock.Deserialize(io.LimitReader(r.r, int64(size))); err != nil {
+		return nil, 0, fmt.Errorf("block at offset %d: %w", offset, err)
+	}
+	// Anything left in the record is not part of the block
+	if err := r.r.skip(offset + int64(size) - r.r.pos); err != nil {
+		return nil, 0, err
+	}
+	return msgBlock, offset, nil
+}
+
+// NextHeader works like Next, but only decodes the block header and skips the transactions. It also returns the
+// size of the block data.
+func (r *Reader) NextHeader() (*wire.BlockHeader, int64, uint32, error) {
+	offset, size, err := r.nextRecord()
+	if err != nil {
+		return nil, 0, 0, err
+	}
+	header := &wire.BlockHeader{}
+	if err := header.Deserialize(io.LimitReader(r.r, int64(size))); err != nil {
+		return nil, 0, 0, fmt.Errorf("block header at offset %d: %w", offset, err)
+	}
+	if err := r.r.skip(offset + int64(size) - r.r.pos); err != nil {
+		return nil, 0, 0, err
+	}
+	return header, offset, size, nil
+}
+
+// nextRecord reads the magic and size prefix of the next record and returns the offset of the block data.
+func (r *Reader) nextRecord() (int64, uint32, error) {
+	var prefix [recordHeaderSize]byte
+	if _, err := io.ReadFull(r.r, prefix[:]); err == io.EOF {
+		return 0, 0, io.EOF
+	} else if err != nil {
+		return 0, 0, fmt.Errorf("record at offset %d: %w", r.r.pos, err)
+	}
+	offset := r.r.pos
+	// Bitcoin Core preallocates the files, the unused tail is zero filled and not obfuscated
+	raw := prefix
+	r.r.apply(raw[:], offset-recordHeaderSize)
+	if binary.LittleEndian.Uint32(raw[:4]) == 0 {
+		return 0, 0, io.EOF
+	}
+	magic := wire.BitcoinNet(binary.LittleEndian.Uint32(prefix[:4]))
+	if magic != r.net {
+		return 0, 0, fmt.Errorf("%w at offset %d: %v", ErrInvalidMagic, offset-recordHeaderSize, magic)
+	}
+	size := binary.LittleEndian.Uint32(prefix[4:])
+	if size > wire.MaxBlockPayload {
+		return 0, 0, fmt.Errorf("%w at offset %d: %d bytes", ErrBlockTooLarge, offset-recordHeaderSize, size)
+	}
+	return offset, size, nil
+}
+
+// xorReader removes the obfuscation of a block file, the key is applied from the start of the file.
+type xorReader struct {
+	source io.Reader
+	r      *bufio.Reader
+	key    []byte
+	// pos is the offset in the file of the next byte to read
+	pos int64
+}
+
+func newXORReader(source io.Reader, key []byte, pos int64) *xorReader {
+	// An all zero key leaves the data unchanged
+	obfuscated := false
+	for _, b := range key {
+		obfuscated = obfuscated || b != 0
+	}
+	if !obfuscated {
+		key = nil
+	}
+	return &xorReader{
+		source: source,
+		r:      bufio.NewReaderSize(source, 1<<16),
+		key:    key,
+		pos:    pos,
+	}
+}
+
+func (x *xorReader) Read(p []byte) (int, error) {
+	n, err := x.r.Read(p)
+	x.apply(p[:n], x.pos)
+	x.pos += int64(n)
+	return n, err
+}
+
+// apply XORs p, read from offset pos of the file, with the key. Applying it twice restores the original bytes.
+func (x *xorReader) apply(p []byte, pos int64) {
+	if len(x.key) == 0 {
+		return
+	}
+	for i := range p {
+		p[i] ^= x.key[(pos+int64(i))%int64(len(x.key))]
+	}
+}
+
+// skip advances n bytes, seeking past what is not buffered when the source supports it.
+func (x *xorReader) skip(n int64) error {
+	if n <= 0 {
+		return nil
+	}
+	seeker, ok := x.source.(io.Seeker)
+	if !ok || n <= int64(x.r.Buffered()) {
+		skipped, err := x.r.Discard(int(n))
+		x.pos += int64(skipped)
+		return err
+	}
+	if _, err := seeker.Seek(n-int64(x.r.Buffered()), io.SeekCurrent); err != nil {
+		return err
+	}
+	x.r.Reset(x.source)
+	x.pos += n
+	return nil
+}
@@ -0,0 +1,50 @@
+package brc20
+
+import (
+	"fmt"
+	"math/big"
+	"strings"
+)
+
+// MaxDecimals is the precision of every BRC-20 amount, a token may use fewer decimals
+const MaxDecimals = 18
+
+var (
+	unit = new(big.Int).Exp(big.NewInt(10), big.NewInt(MaxDecimals), nil)
+	// MaxAmount is the largest amount of any field, 2^64-1 whole tokens
+	MaxAmount = new(big.Int).Mul(new(big.Int).SetUint64(1<<64-1), unit)
+)
+
+// ParseAmount parses a BRC-20 numeric string: decimal digits with at most one dot that neither starts nor ends it,
+// without sign, exponent or spaces, and with at most decimals digits after the dot. The amount is returned in units
+// of 10^-18.
+func ParseAmount(s string, decimals int) (*big.Int, error) {
+	if s == "" || s[0] == '.' || s[len(s)-1] == '.' || strings.Count(s, ".") > 1 ||
+		strings.Trim(s, "0123456789.") != "" {
+		return nil, fmt.Errorf("%w %q", ErrInvalidNumber, s)
+	}
+	integer, fraction, _ := strings.Cut(s, ".")
+	if len(fraction) > decimals {
+		return nil, fmt.Errorf("%w: %q has more than %d decimals", ErrPrecision, s, decimals)
+	}
+	amount, _ := new(big.Int).SetString(integer+fraction+strings.Repeat("0", MaxDecimals-len(fraction)), 10)
+	return amount, nil
+}
+
+// FormatAmount formats a non-negative amount in units of 10^-18 without trailing zeros.
+func FormatAmount(amount *big.Int) string {
+	integer, fraction := new(big.Int).QuoRem(amount, unit, new(big.Int))
+	if fraction.Sign() == 0 {
+		return integer.String()
+	}
+	digits := fmt.Sprintf("%018s", fraction.String())
+	return integer.String() + "." + strings.TrimRight(digits, "0")
+}
+
+// decimalPlaces returns the number of digits after the dot of a numeric string.
+func decimalPlaces(s string) int {
+	if _, fraction, ok := strings.Cut(s, "."); ok {
+		return len(fraction)
+	}
+	return 0
+}
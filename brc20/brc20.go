@@ -0,0 +1,238 @@
+// Package brc20 parses BRC-20 operations from inscriptions. Parsing is strict: an inscription is either a valid
+// deploy, mint or transfer, or rejected with an error that wraps one of the Err* reasons.
+package brc20
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/balletcrypto/bitcoin-inscription-parser/parser"
+)
+
+// Protocol is the value of the p field of every BRC-20 inscription
+const Protocol = "brc-20"
+
+// Op is the op field of an operation.
+type Op string
+
+const (
+	OpDeploy   Op = "deploy"
+	OpMint     Op = "mint"
+	OpTransfer Op = "transfer"
+)
+
+// Reasons for rejecting an inscription
+var (
+	ErrContentType     = errors.New("content type is not text/plain or application/json")
+	ErrInvalidJSON     = errors.New("content is not a JSON object")
+	ErrProtocol        = errors.New("p is not brc-20")
+	ErrOp              = errors.New("unknown op")
+	ErrMissingField    = errors.New("missing field")
+	ErrNotString       = errors.New("field is not a string")
+	ErrTick            = errors.New("tick is not 4 or 5 bytes")
+	ErrInvalidNumber   = errors.New("invalid number")
+	ErrDecimals        = errors.New("dec is above 18")
+	ErrPrecision       = errors.New("too many decimals")
+	ErrAmountRange     = errors.New("amount is zero or above the maximum")
+	ErrSelfMintMissing = errors.New("5 byte tick deploys must be self mint")
+)
+
+// Operation is a *Deploy, *Mint or *Transfer.
+type Operation interface {
+	Op() Op
+	// Tick returns the lowercase ticker, ticks are case insensitive
+	Tick() string
+}
+
+// Deploy creates a token.
+type Deploy struct {
+	Ticker string
+	// Max is the maximum supply and Limit the maximum amount of a mint, both in units of 10^-18
+	Max   *big.Int
+	Limit *big.Int
+	// Decimals is the precision of the token, 18 by default
+	Decimals int
+	// SelfMint restricts mints to children of the deploy inscription, it is required for 5 byte ticks
+	SelfMint bool
+}
+
+// Mint mints tokens to the owner of the inscription.
+type Mint struct {
+	Ticker string
+	// Amount is in units of 10^-18, and AmountDecimals is the number of decimals it was written with, which must not
+	// exceed the decimals of the token
+	Amount         *big.Int
+	AmountDecimals int
+}
+
+// Transfer makes tokens transferable, sending the inscription sends them.
+type Transfer struct {
+	Ticker         string
+	Amount         *big.Int
+	AmountDecimals int
+}
+
+func (d *Deploy) Op() Op         { return OpDeploy }
+func (d *Deploy) Tick() string   { return d.Ticker }
+func (m *Mint) Op() Op           { return OpMint }
+func (m *Mint) Tick() string     { return m.Ticker }
+func (t *Transfer) Op() Op       { return OpTransfer }
+func (t *Transfer) Tick() string { return t.Ticker }
+
+// fields holds the members of the JSON object, of which only strings are ever valid
+type fields map[string]json.RawMessage
+
+func (f fields) string(name string) (value string, ok bool, err error) {
+	raw, ok := f[name]
+	if !ok {
+		return "", false, nil
+	}
+	if err := json.Unmarshal(raw, &value); err != nil || !bytes.HasPrefix(bytes.TrimSpace(raw), []byte(`"`)) {
+		return "", true, fmt.Errorf("%w: %s", ErrNotString, name)
+	}
+	return value, true, nil
+}
+
+func (f fields) requiredString(name string) (string, error) {
+	value, ok, err := f.string(name)
+	if err != nil {
+		return "", err
+	}
+	if !ok {
+		return "", fmt.Errorf("%w: %s", ErrMissingField, name)
+	}
+	return value, nil
+}
+
+// Parse parses the BRC-20 operation of an inscription.
+func Parse(inscription *parser.InscriptionContent) (Operation, error) {
+	contentType, _, _ := strings.Cut(string(inscription.ContentType), ";")
+	if contentType != "text/plain" && contentType != "application/json" {
+		return nil, fmt.Errorf("%w: %q", ErrContentType, inscription.ContentType)
+	}
+	return ParseJSON(inscription.ContentBody)
+}
+
+// ParseJSON parses a BRC-20 operation from the body of an inscription.
+func ParseJSON(body []byte) (Operation, error) {
+	var f fields
+	if err := json.Unmarshal(body, &f); err != nil || f == nil {
+		return nil, ErrInvalidJSON
+	}
+
+	protocol, err := f.requiredString("p")
+	if err != nil {
+		return nil, err
+	}
+	if protocol != Protocol {
+		return nil, fmt.Errorf("%w: %q", ErrProtocol, protocol)
+	}
+	op, err := f.requiredString("op")
+	if err != nil {
+		return nil, err
+	}
+	tick, err := f.requiredString("tick")
+	if err != nil {
+		return nil, err
+	}
+	if len(tick) != 4 && len(tick) != 5 {
+		return nil, fmt.Errorf("%w: %q", ErrTick, tick)
+	}
+	tick = strings.ToLower(tick)
+
+	switch Op(op) {
+	case OpDeploy:
+		return parseDeploy(f, tick)
+	case OpMint:
+		amount, decimals, err := parseAmountField(f, "amt", MaxDecimals)
+		if err != nil {
+			return nil, err
+		}
+		return &Mint{Ticker: tick, Amount: amount, AmountDecimals: decimals}, nil
+	case OpTransfer:
+		amount, decimals, err := parseAmountField(f, "amt", MaxDecimals)
+		if err != nil {
+			return nil, err
+		}
+		return &Transfer{Ticker: tick, Amount: amount, AmountDecimals: decimals}, nil
+	default:
+		return nil, fmt.Errorf("%w %q", ErrOp, op)
+	}
+}
+
+func parseDeploy(f fields, tick string) (*Deploy, error) {
+	deploy := &Deploy{Ticker: tick, Decimals: MaxDecimals}
+	if len(tick) == 5 {
+		// Only 5 byte ticks can be self mint
+		selfMint, _, err := f.string("self_mint")
+		if err != nil {
+			return nil, err
+		}
+		if selfMint != "true" {
+			return nil, ErrSelfMintMissing
+		}
+		deploy.SelfMint = true
+	}
+
+	dec, ok, err := f.string("dec")
+	if err != nil {
+		return nil, err
+	}
+	if ok {
+		if dec == "" || strings.Trim(dec, "0123456789") != "" {
+			return nil, fmt.Errorf("%w: dec %q", ErrInvalidNumber, dec)
+		}
+		if len(strings.TrimLeft(dec, "0")) > 2 || decimalsValue(dec) > MaxDecimals {
+			return nil, fmt.Errorf("%w: %q", ErrDecimals, dec)
+		}
+		deploy.Decimals = decimalsValue(dec)
+	}
+
+	max, err := f.requiredString("max")
+	if err != nil {
+		return nil, err
+	}
+	// A self mint token may leave its supply unlimited with a zero max
+	if amount, err := ParseAmount(max, deploy.Decimals); deploy.SelfMint && err == nil && amount.Sign() == 0 {
+		deploy.Max = new(big.Int).Set(MaxAmount)
+	} else if deploy.Max, _, err = parseAmountField(f, "max", deploy.Decimals); err != nil {
+		return nil, err
+	}
+
+	deploy.Limit = deploy.Max
+	if _, ok := f["lim"]; ok {
+		if deploy.Limit, _, err = parseAmountField(f, "lim", deploy.Decimals); err != nil {
+			return nil, err
+		}
+	}
+	return deploy, nil
+}
+
+// parseAmountField parses a positive amount of at most MaxAmount, returning the number of decimals it was written
+// with.
+func parseAmountField(f fields, name string, decimals int) (*big.Int, int, error) {
+	value, err := f.requiredString(name)
+	if err != nil {
+		return nil, 0, err
+	}
+	amount, err := ParseAmount(value, decimals)
+	if err != nil {
+		return nil, 0, fmt.Errorf("%s: %w", name, err)
+	}
+	if amount.Sign() <= 0 || amount.Cmp(MaxAmount) > 0 {
+		return nil, 0, fmt.Errorf("%w: %s %q", ErrAmountRange, name, value)
+	}
+	return amount, decimalPlaces(value), nil
+}
+
+func decimalsValue(dec string) int {
+	value := 0
+	for _, c := range strings.TrimLeft(dec, "0") {
+		value = value*10 + int(c-'0')
+	}
+	return value
+}
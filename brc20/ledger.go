@@ -0,0 +1,297 @@
+package brc20
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"sort"
+	"strings"
+
+	"github.com/balletcrypto/bitcoin-inscription-parser/parser"
+)
+
+// SelfMintActivationHeight is the mainnet height from which 5 byte ticks, and so self mint tokens, are valid
+const SelfMintActivationHeight int32 = 837090
+
+// Reasons for the ledger to ignore an operation
+var (
+	ErrOutOfOrder           = errors.New("event before the last applied block")
+	ErrCursed               = errors.New("cursed inscriptions are not brc-20 operations")
+	ErrNoOwner              = errors.New("inscription sent to fee on reveal")
+	ErrSelfMintNotActive    = errors.New("5 byte ticks are not active yet")
+	ErrAlreadyDeployed      = errors.New("tick already deployed")
+	ErrNotDeployed          = errors.New("tick not deployed")
+	ErrMintLimit            = errors.New("amount above the mint limit")
+	ErrMintedOut            = errors.New("supply fully minted")
+	ErrSelfMintParent       = errors.New("self mint inscription is not a child of the deploy inscription")
+	ErrInsufficientBalance  = errors.New("amount above the available balance")
+	ErrTransferAlreadyMoved = errors.New("transfer inscription already used")
+)
+
+// InscribeEvent is the reveal of an inscription, e.g. one returned by parser.ParseInscriptionsFromTransaction.
+type InscribeEvent struct {
+	Inscription *parser.TransactionInscription
+	// Number is the inscription number, cursed inscriptions are ignored
+	Number int32
+	Height int32
+	// Owner identifies the output the inscription was revealed to, e.g. its address or hex pkScript
+	Owner string
+	// SentToFee is set when the reveal sent the inscription to fee, so that it landed in the coinbase
+	SentToFee bool
+}
+
+// TransferEvent is a move of an inscription to a new output.
+type TransferEvent struct {
+	ID     parser.InscriptionID
+	Height int32
+	// To identifies the new output, it is ignored when the inscription is sent to fee
+	To string
+	// SentToFee is set when the transaction spent the inscription as fee, so that it landed in the coinbase
+	SentToFee bool
+}
+
+// Token is the state of a deployed token.
+type Token struct {
+	Deploy
+	DeployID     parser.InscriptionID
+	DeployHeight int32
+	// Minted is the supply minted so far, in units of 10^-18
+	Minted *big.Int
+}
+
+// Balance is the balance of a token held by an owner, in units of 10^-18.
+type Balance struct {
+	// Available can be inscribed into transfer inscriptions
+	Available *big.Int
+	// Transferable is held by transfer inscriptions that have not moved yet
+	Transferable *big.Int
+}
+
+// transferInscription is a valid transfer inscription, transfer is nil once it moved
+type transferInscription struct {
+	transfer *Transfer
+	owner    string
+}
+
+// Ledger applies BRC-20 operations. Events must be applied in block order, and in transaction order within a block.
+type Ledger struct {
+	height    int32
+	tokens    map[string]*Token
+	balances  map[string]map[string]*Balance
+	transfers map[parser.InscriptionID]*transferInscription
+}
+
+func NewLedger() *Ledger {
+	return &Ledger{
+		tokens:    map[string]*Token{},
+		balances:  map[string]map[string]*Balance{},
+		transfers: map[parser.InscriptionID]*transferInscription{},
+	}
+}
+
+// Inscribe applies the operation of a revealed inscription, returning the operation or why it was ignored.
+// Inscriptions that are not BRC-20 operations return the error of Parse.
+//
+// A mint or transfer inscription sent to fee on reveal has no owner and is ignored, a deploy is not.
+func (l *Ledger) Inscribe(event *InscribeEvent) (Operation, error) {
+	if err := l.advance(event.Height); err != nil {
+		return nil, err
+	}
+	operation, err := Parse(event.Inscription.Inscription)
+	if err != nil {
+		return nil, err
+	}
+	if event.Number < 0 {
+		return nil, ErrCursed
+	}
+	if len(operation.Tick()) == 5 && event.Height < SelfMintActivationHeight {
+		return nil, fmt.Errorf("%w: %s at height %d", ErrSelfMintNotActive, operation.Tick(), event.Height)
+	}
+
+	switch operation := operation.(type) {
+	case *Deploy:
+		err = l.deploy(event, operation)
+	case *Mint:
+		err = l.mint(event, operation)
+	case *Transfer:
+		err = l.inscribeTransfer(event, operation)
+	}
+	if err != nil {
+		return nil, err
+	}
+	return operation, nil
+}
+
+func (l *Ledger) deploy(event *InscribeEvent, deploy *Deploy) error {
+	if _, ok := l.tokens[deploy.Ticker]; ok {
+		return fmt.Errorf("%w: %s", ErrAlreadyDeployed, deploy.Ticker)
+	}
+	l.tokens[deploy.Ticker] = &Token{
+		Deploy:       *deploy,
+		DeployID:     event.Inscription.InscriptionID,
+		DeployHeight: event.Height,
+		Minted:       new(big.Int),
+	}
+	return nil
+}
+
+func (l *Ledger) mint(event *InscribeEvent, mint *Mint) error {
+	token, err := l.token(mint.Ticker, mint.AmountDecimals)
+	if err != nil {
+		return err
+	}
+	if event.SentToFee {
+		return ErrNoOwner
+	}
+	if mint.Amount.Cmp(token.Limit) > 0 {
+		return fmt.Errorf("%w: %s above %s", ErrMintLimit, FormatAmount(mint.Amount), FormatAmount(token.Limit))
+	}
+	if token.SelfMint && !isChildOf(event.Inscription.Inscription, token.DeployID) {
+		return ErrSelfMintParent
+	}
+	remaining := new(big.Int).Sub(token.Max, token.Minted)
+	if remaining.Sign() <= 0 {
+		return fmt.Errorf("%w: %s", ErrMintedOut, token.Ticker)
+	}
+	// The last mint gets whatever is left of the supply
+	amount := mint.Amount
+	if amount.Cmp(remaining) > 0 {
+		amount = remaining
+	}
+	token.Minted.Add(token.Minted, amount)
+	balance := l.balance(event.Owner, token.Ticker)
+	balance.Available.Add(balance.Available, amount)
+	return nil
+}
+
+func (l *Ledger) inscribeTransfer(event *InscribeEvent, transfer *Transfer) error {
+	if _, err := l.token(transfer.Ticker, transfer.AmountDecimals); err != nil {
+		return err
+	}
+	if event.SentToFee {
+		return ErrNoOwner
+	}
+	balance := l.balance(event.Owner, transfer.Ticker)
+	if transfer.Amount.Cmp(balance.Available) > 0 {
+		return fmt.Errorf("%w: %s above %s", ErrInsufficientBalance, FormatAmount(transfer.Amount),
+			FormatAmount(balance.Available))
+	}
+	balance.Available.Sub(balance.Available, transfer.Amount)
+	balance.Transferable.Add(balance.Transferable, transfer.Amount)
+	l.transfers[event.Inscription.InscriptionID] = &transferInscription{transfer: transfer, owner: event.Owner}
+	return nil
+}
+
+// Transfer applies the move of an inscription. Only the first move of a valid transfer inscription sends tokens,
+// it returns the applied transfer. Any other inscription returns nil, and a transfer inscription that already moved
+// returns ErrTransferAlreadyMoved.
+//
+// A transfer inscription sent to fee returns the tokens to the available balance of its owner, rather than giving
+// them to the miner whose coinbase it lands in.
+func (l *Ledger) Transfer(event *TransferEvent) (*Transfer, error) {
+	if err := l.advance(event.Height); err != nil {
+		return nil, err
+	}
+	inscription, ok := l.transfers[event.ID]
+	if !ok {
+		return nil, nil
+	}
+	if inscription.transfer == nil {
+		return nil, fmt.Errorf("%w: %s", ErrTransferAlreadyMoved, event.ID)
+	}
+
+	transfer := inscription.transfer
+	sender := l.balance(inscription.owner, transfer.Ticker)
+	sender.Transferable.Sub(sender.Transferable, transfer.Amount)
+	to := event.To
+	if event.SentToFee {
+		to = inscription.owner
+	}
+	receiver := l.balance(to, transfer.Ticker)
+	receiver.Available.Add(receiver.Available, transfer.Amount)
+	// Keep the inscription to tell a used transfer inscription from any other inscription
+	inscription.transfer = nil
+	return transfer, nil
+}
+
+// Token returns the token deployed with the tick, ticks are case insensitive.
+func (l *Ledger) Token(tick string) (*Token, bool) {
+	token, ok := l.tokens[strings.ToLower(tick)]
+	return token, ok
+}
+
+// Tokens returns every deployed token by tick.
+func (l *Ledger) Tokens() []*Token {
+	tokens := make([]*Token, 0, len(l.tokens))
+	for _, token := range l.tokens {
+		tokens = append(tokens, token)
+	}
+	sort.Slice(tokens, func(i, j int) bool {
+		return tokens[i].Ticker < tokens[j].Ticker
+	})
+	return tokens
+}
+
+// Balance returns the balance of the token held by the owner, zero if it holds none.
+func (l *Ledger) Balance(owner, tick string) Balance {
+	if balance, ok := l.balances[owner][strings.ToLower(tick)]; ok {
+		return Balance{
+			Available:    new(big.Int).Set(balance.Available),
+			Transferable: new(big.Int).Set(balance.Transferable),
+		}
+	}
+	return Balance{Available: new(big.Int), Transferable: new(big.Int)}
+}
+
+// Owners returns every owner that ever held a token, sorted.
+func (l *Ledger) Owners() []string {
+	owners := make([]string, 0, len(l.balances))
+	for owner := range l.balances {
+		owners = append(owners, owner)
+	}
+	sort.Strings(owners)
+	return owners
+}
+
+func (l *Ledger) advance(height int32) error {
+	if height < l.height {
+		return fmt.Errorf("%w: height %d, last applied height %d", ErrOutOfOrder, height, l.height)
+	}
+	l.height = height
+	return nil
+}
+
+// token returns the deployed token, checking that an amount written with the given decimals fits its precision.
+func (l *Ledger) token(tick string, amountDecimals int) (*Token, error) {
+	token, ok := l.tokens[tick]
+	if !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNotDeployed, tick)
+	}
+	if amountDecimals > token.Decimals {
+		return nil, fmt.Errorf("%w: %d decimals for %s with %d", ErrPrecision, amountDecimals, tick, token.Decimals)
+	}
+	return token, nil
+}
+
+func (l *Ledger) balance(owner, tick string) *Balance {
+	balances, ok := l.balances[owner]
+	if !ok {
+		balances = map[string]*Balance{}
+		l.balances[owner] = balances
+	}
+	balance, ok := balances[tick]
+	if !ok {
+		balance = &Balance{Available: new(big.Int), Transferable: new(big.Int)}
+		balances[tick] = balance
+	}
+	return balance
+}
+
+func isChildOf(inscription *parser.InscriptionContent, parent parser.InscriptionID) bool {
+	for _, id := range inscription.Parents {
+		if id == parent {
+			return true
+		}
+	}
+	return false
+}
@@ -0,0 +1,413 @@
+// Package cbor decodes the CBOR (RFC 8949) encoded metadata stored in inscription envelopes into generic Go values.
+//
+// Decoded values use the following types:
+//
+//	unsigned integer  uint64
+//	negative integer  int64, or *big.Int if it does not fit
+//	byte string       []byte
+//	text string       string
+//	array             []interface{}
+//	map               Map
+//	tag               Tag, or *big.Int for the bignum tags 2 and 3
+//	float             float64
+//	true, false       bool
+//	null              nil
+//	undefined         Undefined
+//	other simple      Simple
+package cbor
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math"
+	"math/big"
+	"unicode/utf8"
+)
+
+var (
+	// ErrUnexpectedEnd is returned when the data ends in the middle of an item
+	ErrUnexpectedEnd = errors.New("cbor: unexpected end of data")
+	// ErrTrailingData is returned when there is data left after the top-level item
+	ErrTrailingData = errors.New("cbor: trailing data after item")
+	// ErrMalformed is returned for reserved additional information values and misplaced break codes
+	ErrMalformed = errors.New("cbor: malformed item")
+	// ErrInvalidUTF8 is returned when a text string is not valid UTF-8
+	ErrInvalidUTF8 = errors.New("cbor: invalid UTF-8 in text string")
+	// ErrTooLarge is returned when the data exceeds DecodeOptions.MaxSize
+	ErrTooLarge = errors.New("cbor: data is too large")
+	// ErrTooDeep is returned when arrays, maps and tags are nested deeper than DecodeOptions.MaxDepth
+	ErrTooDeep = errors.New("cbor: nesting is too deep")
+	// ErrTooManyItems is returned when the data holds more than DecodeOptions.MaxItems items
+	ErrTooManyItems = errors.New("cbor: too many items")
+)
+
+// Map is a decoded CBOR map. Entries keep their encoded order, and keys may be of any type.
+type Map []MapEntry
+
+type MapEntry struct {
+	Key   interface{}
+	Value interface{}
+}
+
+// Get returns the value of the first entry with the given text key.
+func (m Map) Get(key string) (interface{}, bool) {
+	for _, entry := range m {
+		if k, ok := entry.Key.(string); ok && k == key {
+			return entry.Value, true
+		}
+	}
+	return nil, false
+}
+
+// Tag is a tagged item other than a bignum.
+type Tag struct {
+	Number  uint64
+	Content interface{}
+}
+
+// Simple is a simple value other than false, true, null and undefined.
+type Simple uint8
+
+// Undefined is the CBOR undefined simple value.
+type Undefined struct{}
+
+// DecodeOptions bounds the resources used to decode untrusted data.
+type DecodeOptions struct {
+	// MaxSize is the maximum size of the encoded data in bytes
+	MaxSize int
+	// MaxDepth is the maximum nesting of arrays, maps and tags
+	MaxDepth int
+	// MaxItems is the maximum total number of data items, including nested ones and map keys
+	MaxItems int
+}
+
+// DefaultDecodeOptions fits any metadata that can be stored on chain, while keeping decoding cheap.
+var DefaultDecodeOptions = DecodeOptions{
+	MaxSize:  4 << 20,
+	MaxDepth: 32,
+	MaxItems: 1 << 16,
+}
+
+const (
+	majorUnsigned = 0
+	majorNegative = 1
+	majorBytes    = 2
+	majorText     = 3
+	majorArray    = 4
+	majorMap      = 5
+	majorTag      = 6
+	majorSimple   = 7
+
+	// additionalIndefinite marks indefinite-length strings, arrays and maps, and the break code in major type 7
+	additionalIndefinite = 31
+	breakCode            = 0xff
+)
+
+// Decode decodes a single CBOR item using DefaultDecodeOptions.
+func Decode(data []byte) (interface{}, error) {
+	return DefaultDecodeOptions.Decode(data)
+}
+
+// Decode decodes a single CBOR item, rejecting any data left after it.
+func (o DecodeOptions) Decode(data []byte) (interface{}, error) {
+	if len(data) > o.MaxSize {
+		return nil, fmt.Errorf("%w: %d bytes", ErrTooLarge, len(data))
+	}
+	d := &decoder{data: data, options: o}
+	value, err := d.decode(0)
+	if err != nil {
+		return nil, err
+	}
+	if d.offset != len(data) {
+		return nil, fmt.Errorf("%w: %d bytes at offset %d", ErrTrailingData, len(data)-d.offset, d.offset)
+	}
+	return value, nil
+}
+
+type decoder struct {
+	data    []byte
+	offset  int
+	items   int
+	options DecodeOptions
+}
+
+func (d *decoder) readByte() (byte, error) {
+	if d.offset >= len(d.data) {
+		return 0, ErrUnexpectedEnd
+	}
+	b := d.data[d.offset]
+	d.offset++
+	return b, nil
+}
+
+func (d *decoder) readBytes(n uint64) ([]byte, error) {
+	if n > uint64(len(d.data)-d.offset) {
+		return nil, ErrUnexpectedEnd
+	}
+	b := d.data[d.offset : d.offset+int(n)]
+	d.offset += int(n)
+	return b, nil
+}
+
+// readHead reads the initial byte of an item and its argument. indefinite is set for additional information 31,
+// whose meaning depends on the major type.
+func (d *decoder) readHead() (major byte, additional byte, argument uint64, indefinite bool, err error) {
+	initial, err := d.readByte()
+	if err != nil {
+		return 0, 0, 0, false, err
+	}
+	major, additional = initial>>5, initial&0x1f
+
+	var size uint64
+	switch {
+	case additional < 24:
+		return major, additional, uint64(additional), false, nil
+	case additional == 24:
+		size = 1
+	case additional == 25:
+		size = 2
+	case additional == 26:
+		size = 4
+	case additional == 27:
+		size = 8
+	case additional == additionalIndefinite:
+		return major, additional, 0, true, nil
+	default:
+		return 0, 0, 0, false, fmt.Errorf("%w: reserved additional information %d at offset %d", ErrMalformed,
+			additional, d.offset-1)
+	}
+
+	b, err := d.readBytes(size)
+	if err != nil {
+		return 0, 0, 0, false, err
+	}
+	var buf [8]byte
+	copy(buf[8-size:], b)
+	return major, additional, binary.BigEndian.Uint64(buf[:]), false, nil
+}
+
+func (d *decoder) decode(depth int) (interface{}, error) {
+	if depth > d.options.MaxDepth {
+		return nil, fmt.Errorf("%w: more than %d levels", ErrTooDeep, d.options.MaxDepth)
+	}
+	if d.items >= d.options.MaxItems {
+		return nil, fmt.Errorf("%w: more than %d", ErrTooManyItems, d.options.MaxItems)
+	}
+	d.items++
+
+	offset := d.offset
+	major, additional, argument, indefinite, err := d.readHead()
+	if err != nil {
+		return nil, err
+	}
+	if indefinite && (major == majorUnsigned || major == majorNegative || major == majorTag) {
+		return nil, fmt.Errorf("%w: indefinite length for major type %d at offset %d", ErrMalformed, major, offset)
+	}
+
+	switch major {
+	case majorUnsigned:
+		return argument, nil
+	case majorNegative:
+		// The encoded value is -1 - argument
+		if argument <= math.MaxInt64 {
+			return -1 - int64(argument), nil
+		}
+		n := new(big.Int).SetUint64(argument)
+		return n.Neg(n).Sub(n, big.NewInt(1)), nil
+	case majorBytes, majorText:
+		b, err := d.decodeString(major, argument, indefinite)
+		if err != nil {
+			return nil, err
+		}
+		if major == majorBytes {
+			return b, nil
+		}
+		if !utf8.Valid(b) {
+			return nil, fmt.Errorf("%w at offset %d", ErrInvalidUTF8, offset)
+		}
+		return string(b), nil
+	case majorArray:
+		return d.decodeArray(depth, argument, indefinite)
+	case majorMap:
+		return d.decodeMap(depth, argument, indefinite)
+	case majorTag:
+		content, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		// Bignums are decoded as numbers, other tags are left to the caller
+		if b, ok := content.([]byte); ok && (argument == 2 || argument == 3) {
+			n := new(big.Int).SetBytes(b)
+			if argument == 3 {
+				n.Neg(n).Sub(n, big.NewInt(1))
+			}
+			return n, nil
+		}
+		return Tag{Number: argument, Content: content}, nil
+	default:
+		return d.decodeSimple(offset, additional, argument, indefinite)
+	}
+}
+
+func (d *decoder) decodeString(major byte, length uint64, indefinite bool) ([]byte, error) {
+	if !indefinite {
+		b, err := d.readBytes(length)
+		if err != nil {
+			return nil, err
+		}
+		// Copy, so the decoded value does not keep the input alive
+		return append([]byte{}, b...), nil
+	}
+
+	// Indefinite-length strings are a sequence of definite-length strings of the same major type
+	b := []byte{}
+	for {
+		if d.offset < len(d.data) && d.data[d.offset] == breakCode {
+			d.offset++
+			return b, nil
+		}
+		offset := d.offset
+		chunkMajor, _, chunkLength, chunkIndefinite, err := d.readHead()
+		if err != nil {
+			return nil, err
+		}
+		if chunkMajor != major || chunkIndefinite {
+			return nil, fmt.Errorf("%w: invalid string chunk at offset %d", ErrMalformed, offset)
+		}
+		chunk, err := d.readBytes(chunkLength)
+		if err != nil {
+			return nil, err
+		}
+		b = append(b, chunk...)
+	}
+}
+
+func (d *decoder) decodeArray(depth int, length uint64, indefinite bool) ([]interface{}, error) {
+	if indefinite {
+		array := []interface{}{}
+		for {
+			if d.offset < len(d.data) && d.data[d.offset] == breakCode {
+				d.offset++
+				return array, nil
+			}
+			value, err := d.decode(depth + 1)
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, value)
+		}
+	}
+
+	// Check the declared length against the remaining data before allocating
+	if length > uint64(len(d.data)-d.offset) {
+		return nil, ErrUnexpectedEnd
+	}
+	array := make([]interface{}, 0, length)
+	for i := uint64(0); i < length; i++ {
+		value, err := d.decode(depth + 1)
+		if err != nil {
+			return nil, err
+		}
+		array = append(array, value)
+	}
+	return array, nil
+}
+
+func (d *decoder) decodeMap(depth int, length uint64, indefinite bool) (Map, error) {
+	if indefinite {
+		m := Map{}
+		for {
+			if d.offset < len(d.data) && d.data[d.offset] == breakCode {
+				d.offset++
+				return m, nil
+			}
+			entry, err := d.decodeMapEntry(depth)
+			if err != nil {
+				return nil, err
+			}
+			m = append(m, entry)
+		}
+	}
+
+	// Every entry takes at least two bytes
+	if length > uint64(len(d.data)-d.offset)/2 {
+		return nil, ErrUnexpectedEnd
+	}
+	m := make(Map, 0, length)
+	for i := uint64(0); i < length; i++ {
+		entry, err := d.decodeMapEntry(depth)
+		if err != nil {
+			return nil, err
+		}
+		m = append(m, entry)
+	}
+	return m, nil
+}
+
+func (d *decoder) decodeMapEntry(depth int) (MapEntry, error) {
+	key, err := d.decode(depth + 1)
+	if err != nil {
+		return MapEntry{}, err
+	}
+	value, err := d.decode(depth + 1)
+	if err != nil {
+		return MapEntry{}, err
+	}
+	return MapEntry{Key: key, Value: value}, nil
+}
+
+func (d *decoder) decodeSimple(offset int, additional byte, argument uint64, indefinite bool) (interface{}, error) {
+	if indefinite {
+		// A break code is only valid inside indefinite-length items, which handle it themselves
+		return nil, fmt.Errorf("%w: unexpected break code at offset %d", ErrMalformed, offset)
+	}
+	switch additional {
+	case 20:
+		return false, nil
+	case 21:
+		return true, nil
+	case 22:
+		return nil, nil
+	case 23:
+		return Undefined{}, nil
+	case 24:
+		// Simple values below 32 must use the one byte encoding
+		if argument < 32 {
+			return nil, fmt.Errorf("%w: invalid simple value encoding at offset %d", ErrMalformed, offset)
+		}
+		return Simple(argument), nil
+	case 25:
+		return halfToFloat64(uint16(argument)), nil
+	case 26:
+		return float64(math.Float32frombits(uint32(argument))), nil
+	case 27:
+		return math.Float64frombits(argument), nil
+	default:
+		return Simple(argument), nil
+	}
+}
+
+// halfToFloat64 converts an IEEE 754 half-precision float, as described in RFC 8949 appendix D.
+func halfToFloat64(half uint16) float64 {
+	exponent := int(half>>10) & 0x1f
+	mantissa := float64(half & 0x3ff)
+
+	var value float64
+	switch exponent {
+	case 0:
+		value = math.Ldexp(mantissa, -24)
+	case 0x1f:
+		if mantissa == 0 {
+			value = math.Inf(1)
+		} else {
+			value = math.NaN()
+		}
+	default:
+		value = math.Ldexp(mantissa+1024, exponent-25)
+	}
+	if half&0x8000 != 0 {
+		return -value
+	}
+	return value
+}
@@ -0,0 +1,98 @@
+package cbor
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"fmt"
+	"math"
+	"math/big"
+	"strconv"
+)
+
+// ToJSON encodes a value returned by Decode as JSON. Map entries keep their order, non-text map keys are replaced by
+// their JSON encoding as a string, byte strings are encoded as hex strings, tags are replaced by their content,
+// undefined and non-finite floats become null, and other simple values become numbers.
+func ToJSON(value interface{}) ([]byte, error) {
+	var buf bytes.Buffer
+	if err := writeJSON(&buf, value); err != nil {
+		return nil, err
+	}
+	return buf.Bytes(), nil
+}
+
+func writeJSON(buf *bytes.Buffer, value interface{}) error {
+	switch v := value.(type) {
+	case nil, Undefined:
+		buf.WriteString("null")
+	case bool:
+		buf.WriteString(strconv.FormatBool(v))
+	case uint64:
+		buf.WriteString(strconv.FormatUint(v, 10))
+	case int64:
+		buf.WriteString(strconv.FormatInt(v, 10))
+	case *big.Int:
+		buf.WriteString(v.String())
+	case Simple:
+		buf.WriteString(strconv.FormatUint(uint64(v), 10))
+	case float64:
+		if math.IsNaN(v) || math.IsInf(v, 0) {
+			buf.WriteString("null")
+			return nil
+		}
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		buf.Write(encoded)
+	case string:
+		encoded, err := json.Marshal(v)
+		if err != nil {
+			return err
+		}
+		buf.Write(encoded)
+	case []byte:
+		buf.WriteString(`"` + hex.EncodeToString(v) + `"`)
+	case Tag:
+		return writeJSON(buf, v.Content)
+	case []interface{}:
+		buf.WriteByte('[')
+		for i, item := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			if err := writeJSON(buf, item); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte(']')
+	case Map:
+		buf.WriteByte('{')
+		for i, entry := range v {
+			if i > 0 {
+				buf.WriteByte(',')
+			}
+			key, ok := entry.Key.(string)
+			if !ok {
+				encodedKey, err := ToJSON(entry.Key)
+				if err != nil {
+					return err
+				}
+				key = string(encodedKey)
+			}
+			encodedKey, err := json.Marshal(key)
+			if err != nil {
+				return err
+			}
+			buf.Write(encodedKey)
+			buf.WriteByte(':')
+			if err := writeJSON(buf, entry.Value); err != nil {
+				return err
+			}
+		}
+		buf.WriteByte('}')
+	default:
+		return fmt.Errorf("cbor: unsupported value type %T", value)
+	}
+	return nil
+}
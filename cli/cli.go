@@ -0,0 +1,235 @@
+// Package cli implements the inscription-parser command, which parses inscriptions from raw transactions, witness
+// scripts and blocks.
+package cli
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"flag"
+	"fmt"
+	"io"
+	"os"
+	"strings"
+
+	"github.com/balletcrypto/bitcoin-inscription-parser/parser"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// ErrUsage is returned by Run when the command line is invalid
+var ErrUsage = errors.New("invalid usage")
+
+// Usage describes the commands of inscription-parser.
+const Usage = `Usage: inscription-parser <command> [flags] [hex|file]
+
+Commands:
+  tx        parse the inscriptions of a raw transaction
+  script    parse the inscriptions of a witness script
+  block     parse the inscriptions of a raw block
+  extract   write the body of each inscription of a transaction or block to disk
+
+The input is read from stdin when omitted. Run inscription-parser <command> -h for the flags of a command.
+`
+
+// Run runs inscription-parser with the arguments following the program name. The input is read from stdin when no
+// hex or file argument is given, the result is written to stdout and flag errors to stderr. Invalid command lines
+// return an error wrapping ErrUsage, and -h returns flag.ErrHelp.
+func Run(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	if len(args) < 1 {
+		fmt.Fprint(stderr, Usage)
+		return fmt.Errorf("%w: missing command", ErrUsage)
+	}
+
+	switch command, args := args[0], args[1:]; command {
+	case "tx":
+		return runTx(args, stdin, stdout, stderr)
+	case "script":
+		return runScript(args, stdin, stdout, stderr)
+	case "block":
+		return runBlock(args, stdin, stdout, stderr)
+	case "extract":
+		return runExtract(args, stdin, stdout, stderr)
+	case "-h", "-help", "--help", "help":
+		fmt.Fprint(stdout, Usage)
+		return nil
+	default:
+		fmt.Fprint(stderr, Usage)
+		return fmt.Errorf("%w: unknown command %q", ErrUsage, command)
+	}
+}
+
+func newFlagSet(name string, stderr io.Writer) *flag.FlagSet {
+	flags := flag.NewFlagSet(name, flag.ContinueOnError)
+	flags.SetOutput(stderr)
+	return flags
+}
+
+func parseFlags(flags *flag.FlagSet, args []string, format *string) error {
+	if err := flags.Parse(args); errors.Is(err, flag.ErrHelp) {
+		return err
+	} else if err != nil {
+		return fmt.Errorf("%w: %v", ErrUsage, err)
+	}
+	if err := checkFormat(*format); err != nil {
+		return fmt.Errorf("%w: %v", ErrUsage, err)
+	}
+	return nil
+}
+
+func runTx(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := newFlagSet("tx", stderr)
+	format := flags.String("format", formatJSON, "output format, json or table")
+	if err := parseFlags(flags, args, format); err != nil {
+		return err
+	}
+
+	msgTx, err := ReadTransaction(flags.Args(), stdin)
+	if err != nil {
+		return err
+	}
+	return writeInscriptions(stdout, *format, transactionOutputs(parser.ParseInscriptionsFromTransaction(msgTx)))
+}
+
+func runScript(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := newFlagSet("script", stderr)
+	format := flags.String("format", formatJSON, "output format, json or table")
+	if err := parseFlags(flags, args, format); err != nil {
+		return err
+	}
+
+	script, err := ReadInput(flags.Args(), stdin)
+	if err != nil {
+		return err
+	}
+	outputs := []*inscriptionOutput{}
+	for i, inscription := range parser.ParseInscriptions(script) {
+		output := newInscriptionOutput(inscription)
+		output.TxInOffset = uint64(i)
+		outputs = append(outputs, output)
+	}
+	return writeInscriptions(stdout, *format, outputs)
+}
+
+func runBlock(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := newFlagSet("block", stderr)
+	format := flags.String("format", formatJSON, "output format, json or table")
+	height := flags.Int("height", 0, "height of the block, used for the block height in the output")
+	if err := parseFlags(flags, args, format); err != nil {
+		return err
+	}
+
+	msgBlock, err := ReadBlock(flags.Args(), stdin)
+	if err != nil {
+		return err
+	}
+	blockInscriptions := parser.ParseInscriptionsFromBlock(msgBlock, int32(*height))
+	return writeInscriptions(stdout, *format, blockOutputs(blockInscriptions))
+}
+
+func runExtract(args []string, stdin io.Reader, stdout, stderr io.Writer) error {
+	flags := newFlagSet("extract", stderr)
+	format := flags.String("format", formatJSON, "output format of the written files, json or table")
+	dir := flags.String("o", ".", "directory to write the bodies to")
+	isBlock := flags.Bool("block", false, "the input is a raw block instead of a raw transaction")
+	height := flags.Int("height", 0, "height of the block, only used with -block")
+	decode := flags.Bool("decode", false, "undo the content encoding, e.g. br or gzip, before writing")
+	if err := parseFlags(flags, args, format); err != nil {
+		return err
+	}
+
+	var inscriptions []*parser.TransactionInscription
+	var blockHeight *int32
+	if *isBlock {
+		msgBlock, err := ReadBlock(flags.Args(), stdin)
+		if err != nil {
+			return err
+		}
+		blockInscriptions := parser.ParseInscriptionsFromBlock(msgBlock, int32(*height))
+		blockHeight = &blockInscriptions.BlockHeight
+		for _, tx := range blockInscriptions.Transactions {
+			inscriptions = append(inscriptions, tx.Inscriptions...)
+		}
+	} else {
+		msgTx, err := ReadTransaction(flags.Args(), stdin)
+		if err != nil {
+			return err
+		}
+		inscriptions = parser.ParseInscriptionsFromTransaction(msgTx)
+	}
+
+	if err := os.MkdirAll(*dir, 0o755); err != nil {
+		return err
+	}
+	files := []*extractOutput{}
+	for _, inscription := range inscriptions {
+		file, err := extractInscription(*dir, inscription, *decode)
+		if err != nil {
+			return err
+		}
+		file.BlockHeight = blockHeight
+		files = append(files, file)
+	}
+	return writeExtracted(stdout, *format, files)
+}
+
+// ReadTransaction decodes the raw transaction given as a hex argument, a file, or stdin.
+func ReadTransaction(args []string, stdin io.Reader) (*wire.MsgTx, error) {
+	data, err := ReadInput(args, stdin)
+	if err != nil {
+		return nil, err
+	}
+	msgTx := &wire.MsgTx{}
+	if err := msgTx.Deserialize(bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("decode transaction: %w", err)
+	}
+	return msgTx, nil
+}
+
+// ReadBlock decodes the raw block given as a hex argument, a file, or stdin.
+func ReadBlock(args []string, stdin io.Reader) (*wire.MsgBlock, error) {
+	data, err := ReadInput(args, stdin)
+	if err != nil {
+		return nil, err
+	}
+	msgBlock := &wire.MsgBlock{}
+	if err := msgBlock.Deserialize(bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("decode block: %w", err)
+	}
+	return msgBlock, nil
+}
+
+// ReadInput returns the bytes given as a hex argument, a file, or stdin. Files and stdin may hold either raw bytes
+// or hex.
+func ReadInput(args []string, stdin io.Reader) ([]byte, error) {
+	var data []byte
+	switch len(args) {
+	case 0:
+		input, err := io.ReadAll(stdin)
+		if err != nil {
+			return nil, err
+		}
+		data = input
+	case 1:
+		file, err := os.ReadFile(args[0])
+		if err != nil {
+			// Not a readable file, so it must be hex. Long hex fails with a name too long error rather than not
+			// found.
+			decoded, hexErr := hex.DecodeString(strings.TrimSpace(args[0]))
+			if hexErr == nil {
+				return decoded, nil
+			}
+			if errors.Is(err, os.ErrNotExist) {
+				return nil, fmt.Errorf("%q is neither a file nor hex", args[0])
+			}
+			return nil, err
+		}
+		data = file
+	default:
+		return nil, fmt.Errorf("%w: expected a single input, got %d", ErrUsage, len(args))
+	}
+
+	if decoded, err := hex.DecodeString(strings.TrimSpace(string(data))); err == nil {
+		return decoded, nil
+	}
+	return data, nil
+}
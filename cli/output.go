@@ -0,0 +1,182 @@
+package cli
+
+import (
+	"encoding/json"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"text/tabwriter"
+
+	"github.com/balletcrypto/bitcoin-inscription-parser/parser"
+)
+
+const (
+	formatJSON  = "json"
+	formatTable = "table"
+)
+
+func checkFormat(format string) error {
+	if format != formatJSON && format != formatTable {
+		return fmt.Errorf("unknown format %q, expected %s or %s", format, formatJSON, formatTable)
+	}
+	return nil
+}
+
+// inscriptionOutput is the JSON form of an inscription, the ID and curses are only known for transactions.
+type inscriptionOutput struct {
+	ID              string          `json:"id,omitempty"`
+	BlockHeight     *int32          `json:"block_height,omitempty"`
+	TxInIndex       uint32          `json:"txin_index"`
+	TxInOffset      uint64          `json:"txin_offset"`
+	ContentType     string          `json:"content_type"`
+	ContentEncoding string          `json:"content_encoding,omitempty"`
+	ContentLength   uint64          `json:"content_length"`
+	Metaprotocol    string          `json:"metaprotocol,omitempty"`
+	Pointer         *uint64         `json:"pointer,omitempty"`
+	Parents         []string        `json:"parents,omitempty"`
+	Delegate        string          `json:"delegate,omitempty"`
+	Rune            string          `json:"rune,omitempty"`
+	Metadata        json.RawMessage `json:"metadata,omitempty"`
+	Curses          []string        `json:"curses,omitempty"`
+}
+
+func newInscriptionOutput(inscription *parser.InscriptionContent) *inscriptionOutput {
+	output := &inscriptionOutput{
+		ContentType:     string(inscription.ContentType),
+		ContentEncoding: string(inscription.ContentEncoding),
+		ContentLength:   inscription.ContentLength,
+		Metaprotocol:    string(inscription.Metaprotocol),
+		Pointer:         inscription.Pointer,
+	}
+	for _, parent := range inscription.Parents {
+		output.Parents = append(output.Parents, parent.String())
+	}
+	if inscription.Delegate != nil {
+		output.Delegate = inscription.Delegate.String()
+	}
+	if inscription.Rune != nil {
+		output.Rune = inscription.Rune.String()
+	}
+	// Metadata that is not valid CBOR is left out rather than failing the whole output
+	if metadata, err := inscription.MetadataJSON(); err == nil {
+		output.Metadata = metadata
+	}
+	return output
+}
+
+func newTransactionInscriptionOutput(inscription *parser.TransactionInscription) *inscriptionOutput {
+	output := newInscriptionOutput(inscription.Inscription)
+	output.ID = inscription.InscriptionID.String()
+	output.TxInIndex = inscription.TxInIndex
+	output.TxInOffset = inscription.TxInOffset
+	for _, curse := range inscription.Curses {
+		output.Curses = append(output.Curses, curse.String())
+	}
+	return output
+}
+
+func transactionOutputs(inscriptions []*parser.TransactionInscription) []*inscriptionOutput {
+	outputs := []*inscriptionOutput{}
+	for _, inscription := range inscriptions {
+		outputs = append(outputs, newTransactionInscriptionOutput(inscription))
+	}
+	return outputs
+}
+
+func blockOutputs(blockInscriptions *parser.BlockInscriptions) []*inscriptionOutput {
+	outputs := []*inscriptionOutput{}
+	for _, tx := range blockInscriptions.Transactions {
+		for _, inscription := range tx.Inscriptions {
+			output := newTransactionInscriptionOutput(inscription)
+			output.BlockHeight = &blockInscriptions.BlockHeight
+			outputs = append(outputs, output)
+		}
+	}
+	return outputs
+}
+
+func writeInscriptions(w io.Writer, format string, outputs []*inscriptionOutput) error {
+	switch format {
+	case formatJSON:
+		return writeJSON(w, outputs)
+	case formatTable:
+		table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(table, "ID\tINPUT\tOFFSET\tCONTENT TYPE\tLENGTH\tCURSES")
+		for _, output := range outputs {
+			fmt.Fprintf(table, "%s\t%d\t%d\t%s\t%d\t%s\n", valueOrDash(output.ID), output.TxInIndex, output.TxInOffset,
+				valueOrDash(output.ContentType), output.ContentLength, valueOrDash(strings.Join(output.Curses, ",")))
+		}
+		return table.Flush()
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
+
+// extractOutput describes a body written by the extract command.
+type extractOutput struct {
+	ID          string `json:"id"`
+	BlockHeight *int32 `json:"block_height,omitempty"`
+	ContentType string `json:"content_type"`
+	Path        string `json:"path"`
+	Size        int    `json:"size"`
+}
+
+func extractInscription(dir string, inscription *parser.TransactionInscription, decode bool) (*extractOutput, error) {
+	body := inscription.Inscription.ContentBody
+	if decode {
+		decoded, err := inscription.Inscription.DecodedBody()
+		if err != nil {
+			return nil, fmt.Errorf("decode body of %s: %w", inscription.InscriptionID, err)
+		}
+		body = decoded
+	}
+	path := ExtractPath(dir, inscription)
+	if err := os.WriteFile(path, body, 0o644); err != nil {
+		return nil, err
+	}
+	return &extractOutput{
+		ID:          inscription.InscriptionID.String(),
+		ContentType: string(inscription.Inscription.ContentType),
+		Path:        path,
+		Size:        len(body),
+	}, nil
+}
+
+// ExtractPath returns the path extract writes the body of the inscription to, <dir>/<inscription id>.<extension> with
+// the extension derived from the content type.
+func ExtractPath(dir string, inscription *parser.TransactionInscription) string {
+	return filepath.Join(dir, inscription.InscriptionID.String()+"."+inscription.Inscription.FileExtension())
+}
+
+func writeExtracted(w io.Writer, format string, files []*extractOutput) error {
+	switch format {
+	case formatJSON:
+		return writeJSON(w, files)
+	case formatTable:
+		table := tabwriter.NewWriter(w, 0, 4, 2, ' ', 0)
+		fmt.Fprintln(table, "ID\tCONTENT TYPE\tSIZE\tPATH")
+		for _, file := range files {
+			fmt.Fprintf(table, "%s\t%s\t%s\t%s\n", file.ID, valueOrDash(file.ContentType), strconv.Itoa(file.Size),
+				file.Path)
+		}
+		return table.Flush()
+	default:
+		return fmt.Errorf("unknown format %q", format)
+	}
+}
+
+func writeJSON(w io.Writer, value interface{}) error {
+	encoder := json.NewEncoder(w)
+	encoder.SetIndent("", "  ")
+	return encoder.Encode(value)
+}
+
+func valueOrDash(value string) string {
+	if value == "" {
+		return "-"
+	}
+	return value
+}
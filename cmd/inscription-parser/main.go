@@ -0,0 +1,33 @@
+// Command inscription-parser parses inscriptions from raw transactions, witness scripts and blocks.
+//
+// Usage:
+//
+//	inscription-parser tx [-format json|table] [hex|file]
+//	inscription-parser script [-format json|table] [hex|file]
+//	inscription-parser block [-format json|table] [-height n] [hex|file]
+//	inscription-parser extract [-format json|table] [-o dir] [-block] [-height n] [-decode] [hex|file]
+//
+// The input is read from stdin when omitted. A file may hold the raw bytes or their hex encoding.
+package main
+
+import (
+	"errors"
+	"flag"
+	"fmt"
+	"os"
+
+	"github.com/balletcrypto/bitcoin-inscription-parser/cli"
+)
+
+func main() {
+	err := cli.Run(os.Args[1:], os.Stdin, os.Stdout, os.Stderr)
+	switch {
+	case err == nil, errors.Is(err, flag.ErrHelp):
+	case errors.Is(err, cli.ErrUsage):
+		fmt.Fprintf(os.Stderr, "inscription-parser: %v\n", err)
+		os.Exit(2)
+	default:
+		fmt.Fprintf(os.Stderr, "inscription-parser: %v\n", err)
+		os.Exit(1)
+	}
+}
@@ -1,7 +1,7 @@
 package main
 
 import (
-	"github.com/balletcrypto/bitcoin-inscription-parser/parser"
+	"github.com/balletcrypto/bitcoin-inscription-parser/source"
 	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/rpcclient"
 	log "github.com/sirupsen/logrus"
@@ -22,6 +22,9 @@ func main() {
 	}
 	defer client.Shutdown()
 
+	// Any other source works the same, e.g. source.NewEsploraSource("https://mempool.space/api", nil)
+	var txSource source.TxSource = source.NewRPCSource(client)
+
 	// Get the raw transaction data of the specified tx hash
 	txHash := "fe76628c921e7894e4f34f036cd081fc4b21009639d6f4fc12577f59818b35b8"
 	hashFromStr, err := chainhash.NewHashFromStr(txHash)
@@ -29,17 +32,16 @@ func main() {
 		log.Fatalf("Get tx hash from string failed, error: %v", err)
 	}
 
-	rawTx, err := client.GetRawTransaction(hashFromStr)
+	transactionInscriptions, err := source.ParseTransaction(txSource, hashFromStr)
 	if err != nil {
 		log.Fatalf("Get raw tx failed, error: %v", err)
 	}
-	transactionInscriptions := parser.ParseInscriptionsFromTransaction(rawTx.MsgTx())
 	if len(transactionInscriptions) == 0 {
 		log.Infof("NO INSCRIPTONS!!!!!")
 	}
 	for _, v := range transactionInscriptions {
 		ins := v
-		log.Infof("INCRIPTION txin index: %d, tx in offset: %d, content type: %s, content length: %d",
-			ins.TxInIndex, ins.TxInOffset, string(ins.Inscription.ContentType), ins.Inscription.ContentLength)
+		log.Infof("INCRIPTION id: %s, txin index: %d, tx in offset: %d, content type: %s, content length: %d",
+			ins.InscriptionID, ins.TxInIndex, ins.TxInOffset, string(ins.Inscription.ContentType), ins.Inscription.ContentLength)
 	}
 }
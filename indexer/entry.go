@@ -0,0 +1,34 @@
+package indexer
+
+import (
+	"github.com/balletcrypto/bitcoin-inscription-parser/parser"
+)
+
+// Entry is the indexed state of an inscription.
+type Entry struct {
+	ID parser.InscriptionID `json:"id"`
+	// Number is negative for cursed inscriptions revealed before the jubilee
+	Number int32 `json:"number"`
+	// SequenceNumber counts every inscription in the order they were indexed, cursed or not
+	SequenceNumber uint32 `json:"sequence_number"`
+	Height         int32  `json:"height"`
+	Timestamp      int64  `json:"timestamp"`
+	// Fee is the fee of the reveal transaction divided between the inscriptions it reveals
+	Fee uint64 `json:"fee"`
+	// GenesisSatPoint is where the reveal transaction put the inscription
+	GenesisSatPoint SatPoint `json:"genesis_satpoint"`
+	// SatPoint is where the inscription is now, after the spends of the indexed blocks
+	SatPoint      SatPoint       `json:"satpoint"`
+	ContentType   string         `json:"content_type,omitempty"`
+	ContentLength uint64         `json:"content_length"`
+	Curses        []parser.Curse `json:"curses,omitempty"`
+	// Vindicated is set for inscriptions with curses revealed at or after the jubilee
+	Vindicated bool `json:"vindicated,omitempty"`
+	// Unbound is set for inscriptions that are not on any sat, see UnboundOutPoint
+	Unbound bool `json:"unbound,omitempty"`
+}
+
+// IsCursed reports whether the inscription has a negative number.
+func (e *Entry) IsCursed() bool {
+	return e.Number < 0
+}
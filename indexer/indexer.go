@@ -0,0 +1,256 @@
+// Package indexer numbers inscriptions the way ord does. It processes blocks in order, gives each inscription its
+// blessed or cursed number, sequence number and genesis satpoint, follows the inscribed sats as they are spent, and
+// keeps the result in a bbolt database.
+package indexer
+
+import (
+	"errors"
+	"fmt"
+	"time"
+
+	"github.com/balletcrypto/bitcoin-inscription-parser/parser"
+	"github.com/balletcrypto/bitcoin-inscription-parser/source"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+	bolt "go.etcd.io/bbolt"
+)
+
+var (
+	// ErrNotFound is returned by the lookups when the inscription is not indexed
+	ErrNotFound = errors.New("inscription not found")
+	// ErrUnexpectedBlock is returned when a block is not the child of the last indexed block
+	ErrUnexpectedBlock = errors.New("block does not extend the indexed chain")
+	// ErrMissingPrevOut is returned when the value of a spent output is neither indexed nor available from the
+	// PrevOutSource
+	ErrMissingPrevOut = errors.New("missing previous output")
+)
+
+// Heights of the first inscription on each network, no earlier block holds inscriptions.
+const (
+	MainNetFirstInscriptionHeight  int32 = 767430
+	TestNet3FirstInscriptionHeight int32 = 2413343
+	SigNetFirstInscriptionHeight   int32 = 112402
+)
+
+// FirstInscriptionHeight returns the height of the first inscription on the given network, 0 for regtest and
+// unknown networks.
+func FirstInscriptionHeight(params *chaincfg.Params) int32 {
+	switch params.Net {
+	case chaincfg.MainNetParams.Net:
+		return MainNetFirstInscriptionHeight
+	case chaincfg.TestNet3Params.Net:
+		return TestNet3FirstInscriptionHeight
+	case chaincfg.SigNetParams.Net:
+		return SigNetFirstInscriptionHeight
+	default:
+		return 0
+	}
+}
+
+// Options configure an Indexer.
+type Options struct {
+	// Params selects the network, for the block subsidy and the jubilee height. The default is mainnet.
+	Params *chaincfg.Params
+	// PrevOutSource provides the value of outputs created before the first indexed block. It is only needed when
+	// indexing does not start at the genesis block, Sync falls back to its block source when it is also a TxSource.
+	PrevOutSource source.TxSource
+	// FirstInscriptionHeight is the height Sync starts at on an empty index. Zero selects the height of the first
+	// inscription of the network, see FirstInscriptionHeight, and a negative height starts at the genesis block.
+	FirstInscriptionHeight int32
+}
+
+// Indexer maintains the inscription index.
+type Indexer struct {
+	db                     *bolt.DB
+	params                 *chaincfg.Params
+	prevOutSource          source.TxSource
+	firstInscriptionHeight int32
+}
+
+// Open opens the index at path, creating it if it does not exist.
+func Open(path string, options *Options) (*Indexer, error) {
+	if options == nil {
+		options = &Options{}
+	}
+	params := options.Params
+	if params == nil {
+		params = &chaincfg.MainNetParams
+	}
+
+	db, err := bolt.Open(path, 0o600, &bolt.Options{Timeout: time.Second})
+	if err != nil {
+		return nil, err
+	}
+	err = db.Update(func(tx *bolt.Tx) error {
+		for _, name := range buckets {
+			if _, err := tx.CreateBucketIfNotExists(name); err != nil {
+				return err
+			}
+		}
+		return nil
+	})
+	if err != nil {
+		_ = db.Close()
+		return nil, err
+	}
+	firstInscriptionHeight := options.FirstInscriptionHeight
+	switch {
+	case firstInscriptionHeight == 0:
+		firstInscriptionHeight = FirstInscriptionHeight(params)
+	case firstInscriptionHeight < 0:
+		firstInscriptionHeight = 0
+	}
+	return &Indexer{
+		db:                     db,
+		params:                 params,
+		prevOutSource:          options.PrevOutSource,
+		firstInscriptionHeight: firstInscriptionHeight,
+	}, nil
+}
+
+func (i *Indexer) Close() error {
+	return i.db.Close()
+}
+
+// Height returns the height of the last indexed block, or -1 if no block is indexed yet.
+func (i *Indexer) Height() (int32, error) {
+	height := int32(-1)
+	err := i.db.View(func(tx *bolt.Tx) error {
+		if last, _, ok := (store{tx}).lastBlock(); ok {
+			height = last
+		}
+		return nil
+	})
+	return height, err
+}
+
+// IndexBlock indexes the block at the given height. The first block may be at any height, every following block
+// must be the child of the previous one. A block is indexed atomically: on error the index is left unchanged.
+func (i *Indexer) IndexBlock(msgBlock *wire.MsgBlock, height int32) error {
+	return i.indexBlock(msgBlock, height, i.prevOutSource)
+}
+
+func (i *Indexer) indexBlock(msgBlock *wire.MsgBlock, height int32, prevOutSource source.TxSource) error {
+	return i.db.Update(func(tx *bolt.Tx) error {
+		s := store{tx}
+		if last, lastHash, ok := s.lastBlock(); ok && (height != last+1 || msgBlock.Header.PrevBlock != lastHash) {
+			return fmt.Errorf("%w: block %s at height %d, last indexed block %s at height %d", ErrUnexpectedBlock,
+				msgBlock.BlockHash(), height, lastHash, last)
+		}
+
+		u := newUpdater(s, i.params, prevOutSource, msgBlock, height)
+		blockInscriptions := parser.ParseInscriptionsFromBlock(msgBlock, height)
+		inscriptions := func(msgTx *wire.MsgTx) []*parser.TransactionInscription {
+			if tx, ok := blockInscriptions.ByTxID[msgTx.TxHash()]; ok {
+				return tx.Inscriptions
+			}
+			return nil
+		}
+		// The coinbase comes last, so that it can collect the inscriptions sent to fee
+		for _, msgTx := range msgBlock.Transactions[1:] {
+			if err := u.indexTransaction(msgTx, inscriptions(msgTx), false); err != nil {
+				return err
+			}
+		}
+		if err := u.indexTransaction(msgBlock.Transactions[0], inscriptions(msgBlock.Transactions[0]), true); err != nil {
+			return err
+		}
+		if err := u.commit(); err != nil {
+			return err
+		}
+		return s.putBlockHash(height, msgBlock.BlockHash())
+	})
+}
+
+// Sync indexes the blocks of the source from the block after the last indexed one up to the best block. An empty
+// index starts at the first inscription height, the values of the outputs of earlier blocks come from the
+// PrevOutSource, or from the block source if it is also a TxSource.
+func (i *Indexer) Sync(blockSource source.BlockSource) error {
+	height, err := i.Height()
+	if err != nil {
+		return err
+	}
+	if height < 0 {
+		height = i.firstInscriptionHeight - 1
+	}
+	prevOutSource := i.prevOutSource
+	if txSource, ok := blockSource.(source.TxSource); ok && prevOutSource == nil {
+		prevOutSource = txSource
+	}
+	bestHeight, err := blockSource.BestHeight()
+	if err != nil {
+		return err
+	}
+	for height < bestHeight {
+		msgBlock, err := source.GetBlockByHeight(blockSource, height+1)
+		if err != nil {
+			return fmt.Errorf("get block %d: %w", height+1, err)
+		}
+		if err := i.indexBlock(msgBlock, height+1, prevOutSource); err != nil {
+			return err
+		}
+		height++
+	}
+	return nil
+}
+
+// InscriptionByID returns the inscription with the given ID.
+func (i *Indexer) InscriptionByID(id parser.InscriptionID) (*Entry, error) {
+	var entry *Entry
+	err := i.db.View(func(tx *bolt.Tx) error {
+		s := store{tx}
+		sequenceNumber, ok := s.sequenceNumberByID(id)
+		if !ok {
+			return fmt.Errorf("inscription %s: %w", id, ErrNotFound)
+		}
+		var err error
+		entry, err = s.entry(sequenceNumber)
+		return err
+	})
+	return entry, err
+}
+
+// InscriptionByNumber returns the inscription with the given number, negative numbers are cursed inscriptions.
+func (i *Indexer) InscriptionByNumber(number int32) (*Entry, error) {
+	var entry *Entry
+	err := i.db.View(func(tx *bolt.Tx) error {
+		s := store{tx}
+		sequenceNumber, ok := s.sequenceNumberByNumber(number)
+		if !ok {
+			return fmt.Errorf("inscription number %d: %w", number, ErrNotFound)
+		}
+		var err error
+		entry, err = s.entry(sequenceNumber)
+		return err
+	})
+	return entry, err
+}
+
+// InscriptionBySequenceNumber returns the inscription with the given sequence number.
+func (i *Indexer) InscriptionBySequenceNumber(sequenceNumber uint32) (*Entry, error) {
+	var entry *Entry
+	err := i.db.View(func(tx *bolt.Tx) error {
+		var err error
+		entry, err = (store{tx}).entry(sequenceNumber)
+		return err
+	})
+	return entry, err
+}
+
+// InscriptionsOnOutput returns the inscriptions currently in the output, by offset.
+func (i *Indexer) InscriptionsOnOutput(outPoint wire.OutPoint) ([]*Entry, error) {
+	var entries []*Entry
+	err := i.db.View(func(tx *bolt.Tx) error {
+		s := store{tx}
+		_, sequenceNumbers := s.inscriptionsOnOutput(outPoint)
+		for _, sequenceNumber := range sequenceNumbers {
+			entry, err := s.entry(sequenceNumber)
+			if err != nil {
+				return err
+			}
+			entries = append(entries, entry)
+		}
+		return nil
+	})
+	return entries, err
+}
@@ -0,0 +1,100 @@
+package indexer
+
+import (
+	"encoding/binary"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// satPointSize is the size of a SatPoint in its binary encoding: the outpoint followed by the big-endian offset
+const satPointSize = outPointSize + 8
+
+// outPointSize is the size of an outpoint in its binary encoding: the txid followed by the little-endian index
+const outPointSize = chainhash.HashSize + 4
+
+var (
+	// UnboundOutPoint holds the inscriptions that were not inscribed on any sat, their offset counts them
+	UnboundOutPoint = wire.OutPoint{}
+	// LostOutPoint holds the inscriptions sent to fee that the coinbase did not claim
+	LostOutPoint = wire.OutPoint{Index: wire.MaxPrevOutIndex}
+)
+
+// SatPoint is the location of a sat: an output and the offset of the sat within it.
+type SatPoint struct {
+	OutPoint wire.OutPoint
+	Offset   uint64
+}
+
+// NewSatPointFromStr parses a satpoint in the <txid>:<vout>:<offset> form used by ord.
+func NewSatPointFromStr(s string) (*SatPoint, error) {
+	parts := strings.Split(s, ":")
+	if len(parts) != 3 {
+		return nil, fmt.Errorf("invalid satpoint %q", s)
+	}
+	txID, err := chainhash.NewHashFromStr(parts[0])
+	if err != nil || len(parts[0]) != chainhash.MaxHashStringSize {
+		return nil, fmt.Errorf("invalid satpoint %q: invalid txid", s)
+	}
+	index, err := strconv.ParseUint(parts[1], 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid satpoint %q: invalid output index", s)
+	}
+	offset, err := strconv.ParseUint(parts[2], 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid satpoint %q: invalid offset", s)
+	}
+	return &SatPoint{
+		OutPoint: wire.OutPoint{Hash: *txID, Index: uint32(index)},
+		Offset:   offset,
+	}, nil
+}
+
+func (s SatPoint) String() string {
+	return fmt.Sprintf("%s:%d", s.OutPoint, s.Offset)
+}
+
+func (s SatPoint) MarshalText() ([]byte, error) {
+	return []byte(s.String()), nil
+}
+
+func (s *SatPoint) UnmarshalText(text []byte) error {
+	satPoint, err := NewSatPointFromStr(string(text))
+	if err != nil {
+		return err
+	}
+	*s = *satPoint
+	return nil
+}
+
+// bytes encodes the satpoint so that the satpoints of an output sort by offset.
+func (s SatPoint) bytes() []byte {
+	key := make([]byte, satPointSize)
+	copy(key, outPointBytes(s.OutPoint))
+	binary.BigEndian.PutUint64(key[outPointSize:], s.Offset)
+	return key
+}
+
+func satPointFromBytes(key []byte) SatPoint {
+	return SatPoint{
+		OutPoint: outPointFromBytes(key[:outPointSize]),
+		Offset:   binary.BigEndian.Uint64(key[outPointSize:satPointSize]),
+	}
+}
+
+func outPointBytes(outPoint wire.OutPoint) []byte {
+	key := make([]byte, outPointSize)
+	copy(key, outPoint.Hash[:])
+	binary.LittleEndian.PutUint32(key[chainhash.HashSize:], outPoint.Index)
+	return key
+}
+
+func outPointFromBytes(key []byte) wire.OutPoint {
+	var outPoint wire.OutPoint
+	copy(outPoint.Hash[:], key[:chainhash.HashSize])
+	outPoint.Index = binary.LittleEndian.Uint32(key[chainhash.HashSize:outPointSize])
+	return outPoint
+}
@@ -0,0 +1,183 @@
+package indexer
+
+import (
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+
+	"github.com/balletcrypto/bitcoin-inscription-parser/parser"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+	bolt "go.etcd.io/bbolt"
+)
+
+// Buckets of the index. Integers in keys are big-endian so that keys sort numerically.
+var (
+	heightToBlockHashBucket        = []byte("height_to_block_hash")
+	statisticsBucket               = []byte("statistics")
+	sequenceNumberToEntryBucket    = []byte("sequence_number_to_entry")
+	idToSequenceNumberBucket       = []byte("inscription_id_to_sequence_number")
+	numberToSequenceNumberBucket   = []byte("inscription_number_to_sequence_number")
+	satPointToSequenceNumberBucket = []byte("satpoint_to_sequence_number")
+	outPointToValueBucket          = []byte("outpoint_to_value")
+
+	buckets = [][]byte{
+		heightToBlockHashBucket,
+		statisticsBucket,
+		sequenceNumberToEntryBucket,
+		idToSequenceNumberBucket,
+		numberToSequenceNumberBucket,
+		satPointToSequenceNumberBucket,
+		outPointToValueBucket,
+	}
+)
+
+// Keys of the statistics bucket
+var (
+	nextSequenceNumberKey  = []byte("next_sequence_number")
+	blessedInscriptionsKey = []byte("blessed_inscriptions")
+	cursedInscriptionsKey  = []byte("cursed_inscriptions")
+	unboundInscriptionsKey = []byte("unbound_inscriptions")
+	lostSatsKey            = []byte("lost_sats")
+)
+
+// store reads and writes the index within a single bbolt transaction.
+type store struct {
+	tx *bolt.Tx
+}
+
+func (s store) statistic(key []byte) uint64 {
+	value := s.tx.Bucket(statisticsBucket).Get(key)
+	if value == nil {
+		return 0
+	}
+	return binary.BigEndian.Uint64(value)
+}
+
+func (s store) setStatistic(key []byte, value uint64) error {
+	return s.tx.Bucket(statisticsBucket).Put(key, uint64Bytes(value))
+}
+
+// lastBlock returns the height and hash of the last indexed block, ok is false for an empty index.
+func (s store) lastBlock() (height int32, blockHash chainhash.Hash, ok bool) {
+	key, value := s.tx.Bucket(heightToBlockHashBucket).Cursor().Last()
+	if key == nil {
+		return 0, chainhash.Hash{}, false
+	}
+	copy(blockHash[:], value)
+	return int32(binary.BigEndian.Uint32(key)), blockHash, true
+}
+
+func (s store) putBlockHash(height int32, blockHash chainhash.Hash) error {
+	return s.tx.Bucket(heightToBlockHashBucket).Put(uint32Bytes(uint32(height)), blockHash[:])
+}
+
+func (s store) entry(sequenceNumber uint32) (*Entry, error) {
+	value := s.tx.Bucket(sequenceNumberToEntryBucket).Get(uint32Bytes(sequenceNumber))
+	if value == nil {
+		return nil, fmt.Errorf("sequence number %d: %w", sequenceNumber, ErrNotFound)
+	}
+	entry := &Entry{}
+	if err := json.Unmarshal(value, entry); err != nil {
+		return nil, fmt.Errorf("sequence number %d: %w", sequenceNumber, err)
+	}
+	return entry, nil
+}
+
+// putEntry writes the entry and, for a new entry, the indexes by inscription ID and number.
+func (s store) putEntry(entry *Entry, isNew bool) error {
+	value, err := json.Marshal(entry)
+	if err != nil {
+		return err
+	}
+	sequenceNumber := uint32Bytes(entry.SequenceNumber)
+	if err := s.tx.Bucket(sequenceNumberToEntryBucket).Put(sequenceNumber, value); err != nil {
+		return err
+	}
+	if !isNew {
+		return nil
+	}
+	id, _ := entry.ID.MarshalBinary()
+	if err := s.tx.Bucket(idToSequenceNumberBucket).Put(id, sequenceNumber); err != nil {
+		return err
+	}
+	return s.tx.Bucket(numberToSequenceNumberBucket).Put(numberBytes(entry.Number), sequenceNumber)
+}
+
+func (s store) sequenceNumberByID(id parser.InscriptionID) (uint32, bool) {
+	key, _ := id.MarshalBinary()
+	value := s.tx.Bucket(idToSequenceNumberBucket).Get(key)
+	if value == nil {
+		return 0, false
+	}
+	return binary.BigEndian.Uint32(value), true
+}
+
+func (s store) sequenceNumberByNumber(number int32) (uint32, bool) {
+	value := s.tx.Bucket(numberToSequenceNumberBucket).Get(numberBytes(number))
+	if value == nil {
+		return 0, false
+	}
+	return binary.BigEndian.Uint32(value), true
+}
+
+// inscriptionsOnOutput returns the satpoints and sequence numbers of the inscriptions in an output, by offset.
+func (s store) inscriptionsOnOutput(outPoint wire.OutPoint) ([]SatPoint, []uint32) {
+	var (
+		satPoints       []SatPoint
+		sequenceNumbers []uint32
+	)
+	prefix := outPointBytes(outPoint)
+	cursor := s.tx.Bucket(satPointToSequenceNumberBucket).Cursor()
+	for key, _ := cursor.Seek(prefix); key != nil && string(key[:outPointSize]) == string(prefix); key, _ = cursor.Next() {
+		satPoints = append(satPoints, satPointFromBytes(key))
+		sequenceNumbers = append(sequenceNumbers, binary.BigEndian.Uint32(key[satPointSize:]))
+	}
+	return satPoints, sequenceNumbers
+}
+
+// putSatPoint records that the inscription is at the satpoint, several inscriptions may share a satpoint.
+func (s store) putSatPoint(satPoint SatPoint, sequenceNumber uint32) error {
+	return s.tx.Bucket(satPointToSequenceNumberBucket).Put(satPointKey(satPoint, sequenceNumber), nil)
+}
+
+func (s store) deleteSatPoint(satPoint SatPoint, sequenceNumber uint32) error {
+	return s.tx.Bucket(satPointToSequenceNumberBucket).Delete(satPointKey(satPoint, sequenceNumber))
+}
+
+func (s store) outPointValue(outPoint wire.OutPoint) (int64, bool) {
+	value := s.tx.Bucket(outPointToValueBucket).Get(outPointBytes(outPoint))
+	if value == nil {
+		return 0, false
+	}
+	return int64(binary.BigEndian.Uint64(value)), true
+}
+
+func (s store) putOutPointValue(outPoint wire.OutPoint, value int64) error {
+	return s.tx.Bucket(outPointToValueBucket).Put(outPointBytes(outPoint), uint64Bytes(uint64(value)))
+}
+
+func (s store) deleteOutPointValue(outPoint wire.OutPoint) error {
+	return s.tx.Bucket(outPointToValueBucket).Delete(outPointBytes(outPoint))
+}
+
+func satPointKey(satPoint SatPoint, sequenceNumber uint32) []byte {
+	return append(satPoint.bytes(), uint32Bytes(sequenceNumber)...)
+}
+
+// numberBytes flips the sign bit, so that cursed numbers sort before blessed ones.
+func numberBytes(number int32) []byte {
+	return uint32Bytes(uint32(number) ^ 0x80000000)
+}
+
+func uint32Bytes(value uint32) []byte {
+	key := make([]byte, 4)
+	binary.BigEndian.PutUint32(key, value)
+	return key
+}
+
+func uint64Bytes(value uint64) []byte {
+	key := make([]byte, 8)
+	binary.BigEndian.PutUint64(key, value)
+	return key
+}
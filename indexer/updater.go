@@ -0,0 +1,303 @@
+package indexer
+
+import (
+	"fmt"
+	"sort"
+
+	"github.com/balletcrypto/bitcoin-inscription-parser/parser"
+	"github.com/balletcrypto/bitcoin-inscription-parser/sat"
+	"github.com/balletcrypto/bitcoin-inscription-parser/source"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// flotsam is an inscription between inputs and outputs, its offset counts sats from the first sat of the first input.
+type flotsam struct {
+	// inscription is set for an inscription revealed by the transaction, entry for one that is already indexed
+	inscription *parser.TransactionInscription
+	entry       *Entry
+	offset      uint64
+	fee         uint64
+	unbound     bool
+}
+
+// updater indexes the transactions of one block, following ord's inscription updater.
+type updater struct {
+	store         store
+	params        *chaincfg.Params
+	prevOutSource source.TxSource
+	height        int32
+	timestamp     int64
+	subsidy       uint64
+	// reward is the subsidy plus the fees of the transactions indexed so far
+	reward uint64
+	// flotsam holds the inscriptions sent to fee, with their offset into the coinbase outputs
+	flotsam []*flotsam
+
+	nextSequenceNumber  uint32
+	blessedInscriptions uint64
+	cursedInscriptions  uint64
+	unboundInscriptions uint64
+	lostSats            uint64
+}
+
+func newUpdater(s store, params *chaincfg.Params, prevOutSource source.TxSource, msgBlock *wire.MsgBlock,
+	height int32) *updater {
+	// Like ord, the subsidy follows the mainnet halving schedule on every network
+	subsidy := sat.Subsidy(height)
+	return &updater{
+		store:               s,
+		params:              params,
+		prevOutSource:       prevOutSource,
+		height:              height,
+		timestamp:           msgBlock.Header.Timestamp.Unix(),
+		subsidy:             subsidy,
+		reward:              subsidy,
+		nextSequenceNumber:  uint32(s.statistic(nextSequenceNumberKey)),
+		blessedInscriptions: s.statistic(blessedInscriptionsKey),
+		cursedInscriptions:  s.statistic(cursedInscriptionsKey),
+		unboundInscriptions: s.statistic(unboundInscriptionsKey),
+		lostSats:            s.statistic(lostSatsKey),
+	}
+}
+
+// indexTransaction moves the inscriptions on the spent outputs and places those revealed by the transaction. Sats
+// are assigned first in first out: the sat at a given offset into the inputs ends up at the same offset into the
+// outputs, or in the coinbase when it is sent to fee. It also records the values of the outputs.
+func (u *updater) indexTransaction(msgTx *wire.MsgTx, inscriptions []*parser.TransactionInscription,
+	isCoinbase bool) error {
+	var totalOutputValue uint64
+	for _, txOut := range msgTx.TxOut {
+		totalOutputValue += uint64(txOut.Value)
+	}
+
+	type inscribedOffset struct {
+		// initialCursedOrVindicated is set when the first inscription on the sat had curses
+		initialCursedOrVindicated bool
+		count                     int
+	}
+	var (
+		floating         []*flotsam
+		inscribedOffsets = map[uint64]*inscribedOffset{}
+		totalInputValue  uint64
+		next             int
+		revealed         uint64
+	)
+	for inputIndex, txIn := range msgTx.TxIn {
+		if isCoinbase {
+			totalInputValue += u.subsidy
+			continue
+		}
+
+		satPoints, sequenceNumbers := u.store.inscriptionsOnOutput(txIn.PreviousOutPoint)
+		for i, satPoint := range satPoints {
+			entry, err := u.store.entry(sequenceNumbers[i])
+			if err != nil {
+				return err
+			}
+			offset := totalInputValue + satPoint.Offset
+			floating = append(floating, &flotsam{entry: entry, offset: offset})
+			if inscribed, ok := inscribedOffsets[offset]; ok {
+				inscribed.count++
+			} else {
+				inscribedOffsets[offset] = &inscribedOffset{
+					initialCursedOrVindicated: entry.IsCursed() || entry.Vindicated,
+					count:                     1,
+				}
+			}
+		}
+
+		inputValue, err := u.inputValue(txIn.PreviousOutPoint)
+		if err != nil {
+			return err
+		}
+		inputOffset := totalInputValue
+		totalInputValue += inputValue
+
+		for ; next < len(inscriptions) && inscriptions[next].TxInIndex == uint32(inputIndex); next++ {
+			inscription := inscriptions[next]
+			offset := inputOffset
+			if pointer := inscription.Inscription.Pointer; pointer != nil && *pointer < totalOutputValue {
+				offset = *pointer
+			}
+
+			// Like ord, only an otherwise uncursed inscription is checked for reinscription. It is cursed unless
+			// the sat holds a single inscription that was itself cursed or vindicated.
+			if inscribed, ok := inscribedOffsets[offset]; ok && !inscription.IsCursed() {
+				if inscribed.count > 1 || !inscribed.initialCursedOrVindicated {
+					inscription.AddCurse(parser.CurseReinscription)
+				}
+			}
+			if inscribed, ok := inscribedOffsets[offset]; ok {
+				inscribed.count++
+			} else {
+				// A revealed inscription with any curse is numbered cursed, or vindicated after the jubilee
+				inscribedOffsets[offset] = &inscribedOffset{initialCursedOrVindicated: inscription.IsCursed(), count: 1}
+			}
+
+			floating = append(floating, &flotsam{
+				inscription: inscription,
+				offset:      offset,
+				unbound:     inputValue == 0 || inscription.Inscription.IsUnrecognizedEvenField,
+			})
+			revealed++
+		}
+	}
+
+	if revealed > 0 && totalInputValue > totalOutputValue {
+		fee := (totalInputValue - totalOutputValue) / revealed
+		for _, f := range floating {
+			if f.inscription != nil {
+				f.fee = fee
+			}
+		}
+	}
+	if isCoinbase {
+		floating = append(floating, u.flotsam...)
+	}
+	sort.SliceStable(floating, func(i, j int) bool {
+		return floating[i].offset < floating[j].offset
+	})
+
+	txID := msgTx.TxHash()
+	var outputValue uint64
+	for vout, txOut := range msgTx.TxOut {
+		end := outputValue + uint64(txOut.Value)
+		for len(floating) > 0 && floating[0].offset < end {
+			satPoint := SatPoint{
+				OutPoint: wire.OutPoint{Hash: txID, Index: uint32(vout)},
+				Offset:   floating[0].offset - outputValue,
+			}
+			if err := u.updateInscriptionLocation(floating[0], satPoint); err != nil {
+				return err
+			}
+			floating = floating[1:]
+		}
+		outputValue = end
+	}
+
+	if isCoinbase {
+		// Whatever the coinbase does not claim is lost
+		for _, f := range floating {
+			satPoint := SatPoint{OutPoint: LostOutPoint, Offset: u.lostSats + f.offset - outputValue}
+			if err := u.updateInscriptionLocation(f, satPoint); err != nil {
+				return err
+			}
+		}
+		if u.reward > outputValue {
+			u.lostSats += u.reward - outputValue
+		}
+	} else {
+		for _, f := range floating {
+			f.offset = u.reward + f.offset - outputValue
+			u.flotsam = append(u.flotsam, f)
+		}
+		if totalInputValue > outputValue {
+			u.reward += totalInputValue - outputValue
+		}
+	}
+
+	return u.updateOutPointValues(msgTx, isCoinbase)
+}
+
+// updateInscriptionLocation moves an indexed inscription to the satpoint, or numbers a new one and records it there.
+func (u *updater) updateInscriptionLocation(f *flotsam, satPoint SatPoint) error {
+	if entry := f.entry; entry != nil {
+		if err := u.store.deleteSatPoint(entry.SatPoint, entry.SequenceNumber); err != nil {
+			return err
+		}
+		entry.SatPoint = satPoint
+		if err := u.store.putEntry(entry, false); err != nil {
+			return err
+		}
+		return u.store.putSatPoint(satPoint, entry.SequenceNumber)
+	}
+
+	inscription := f.inscription
+	if f.unbound {
+		satPoint = SatPoint{OutPoint: UnboundOutPoint, Offset: u.unboundInscriptions}
+		u.unboundInscriptions++
+	}
+
+	entry := &Entry{
+		ID:              inscription.InscriptionID,
+		SequenceNumber:  u.nextSequenceNumber,
+		Height:          u.height,
+		Timestamp:       u.timestamp,
+		Fee:             f.fee,
+		GenesisSatPoint: satPoint,
+		SatPoint:        satPoint,
+		ContentType:     string(inscription.Inscription.ContentType),
+		ContentLength:   inscription.Inscription.ContentLength,
+		Curses:          inscription.Curses,
+		Vindicated:      inscription.IsVindicatedAt(u.height, u.params),
+		Unbound:         f.unbound,
+	}
+	u.nextSequenceNumber++
+	if inscription.IsCursedAt(u.height, u.params) {
+		u.cursedInscriptions++
+		entry.Number = -int32(u.cursedInscriptions)
+	} else {
+		entry.Number = int32(u.blessedInscriptions)
+		u.blessedInscriptions++
+	}
+
+	if err := u.store.putEntry(entry, true); err != nil {
+		return err
+	}
+	return u.store.putSatPoint(satPoint, entry.SequenceNumber)
+}
+
+// updateOutPointValues adds the outputs of the transaction to the indexed output values and removes the outputs it
+// spends.
+func (u *updater) updateOutPointValues(msgTx *wire.MsgTx, isCoinbase bool) error {
+	if !isCoinbase {
+		for _, txIn := range msgTx.TxIn {
+			if err := u.store.deleteOutPointValue(txIn.PreviousOutPoint); err != nil {
+				return err
+			}
+		}
+	}
+	txID := msgTx.TxHash()
+	for vout, txOut := range msgTx.TxOut {
+		if err := u.store.putOutPointValue(wire.OutPoint{Hash: txID, Index: uint32(vout)}, txOut.Value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
+
+// inputValue returns the value of the spent output, from the index or else from the PrevOutSource.
+func (u *updater) inputValue(outPoint wire.OutPoint) (uint64, error) {
+	if value, ok := u.store.outPointValue(outPoint); ok {
+		return uint64(value), nil
+	}
+	if u.prevOutSource == nil {
+		return 0, fmt.Errorf("%w %s", ErrMissingPrevOut, outPoint)
+	}
+	msgTx, err := u.prevOutSource.GetTransaction(&outPoint.Hash)
+	if err != nil {
+		return 0, fmt.Errorf("%w %s: %v", ErrMissingPrevOut, outPoint, err)
+	}
+	if int(outPoint.Index) >= len(msgTx.TxOut) {
+		return 0, fmt.Errorf("%w %s: output index out of range", ErrMissingPrevOut, outPoint)
+	}
+	return uint64(msgTx.TxOut[outPoint.Index].Value), nil
+}
+
+// commit saves the counters of the block.
+func (u *updater) commit() error {
+	statistics := map[string]uint64{
+		string(nextSequenceNumberKey):  uint64(u.nextSequenceNumber),
+		string(blessedInscriptionsKey): u.blessedInscriptions,
+		string(cursedInscriptionsKey):  u.cursedInscriptions,
+		string(unboundInscriptionsKey): u.unboundInscriptions,
+		string(lostSatsKey):            u.lostSats,
+	}
+	for key, value := range statistics {
+		if err := u.store.setStatistic([]byte(key), value); err != nil {
+			return err
+		}
+	}
+	return nil
+}
@@ -0,0 +1,93 @@
+package mint
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/balletcrypto/bitcoin-inscription-parser/parser"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// BatchMode decides where the inscriptions of a batch land in the reveal transaction, like ord's batch modes.
+type BatchMode int
+
+const (
+	// BatchModeSameSat inscribes every inscription on the first sat of a single output
+	BatchModeSameSat BatchMode = iota
+	// BatchModeSeparateOutputs gives each inscription its own output
+	BatchModeSeparateOutputs
+	// BatchModeSharedOutput inscribes each inscription on its own sat of a single output
+	BatchModeSharedOutput
+)
+
+// ErrInvalidBatch is returned when the batch has no inscriptions or the destinations do not match the mode
+var ErrInvalidBatch = errors.New("invalid batch")
+
+// Batch reveals several inscriptions with a single commit output. Every envelope is in the same tapscript, and the
+// pointer field of each inscription after the first points it to its sat in the reveal outputs.
+type Batch struct {
+	Commit *Commit
+	// Inscriptions are copies of the requested inscriptions with their pointer set
+	Inscriptions []*parser.InscriptionContent
+	// Outputs are the reveal outputs, in order
+	Outputs []*wire.TxOut
+}
+
+// NewBatch plans a batch reveal. Every output is worth postage sats per inscription it holds. BatchModeSeparateOutputs
+// takes either one destination per inscription or a single destination shared by every output, the other modes
+// take a single destination.
+func NewBatch(key *btcec.PublicKey, params *chaincfg.Params, mode BatchMode, postage int64, destinations [][]byte,
+	inscriptions ...*parser.InscriptionContent) (*Batch, error) {
+	if len(inscriptions) == 0 {
+		return nil, fmt.Errorf("%w: no inscriptions", ErrInvalidBatch)
+	}
+	if len(destinations) != 1 && (mode != BatchModeSeparateOutputs || len(destinations) != len(inscriptions)) {
+		return nil, fmt.Errorf("%w: %d destinations for %d inscriptions", ErrInvalidBatch, len(destinations),
+			len(inscriptions))
+	}
+
+	batch := &Batch{}
+	switch mode {
+	case BatchModeSameSat:
+		batch.Outputs = []*wire.TxOut{wire.NewTxOut(postage, destinations[0])}
+	case BatchModeSharedOutput:
+		batch.Outputs = []*wire.TxOut{wire.NewTxOut(postage*int64(len(inscriptions)), destinations[0])}
+	case BatchModeSeparateOutputs:
+		for i := range inscriptions {
+			batch.Outputs = append(batch.Outputs, wire.NewTxOut(postage, destinations[i%len(destinations)]))
+		}
+	default:
+		return nil, fmt.Errorf("%w: unknown mode %d", ErrInvalidBatch, mode)
+	}
+
+	for i, v := range inscriptions {
+		inscription := *v
+		inscription.Pointer = nil
+		// The first inscription lands on the first sat without a pointer, which would curse it before the jubilee
+		if i > 0 && mode != BatchModeSameSat {
+			pointer := uint64(postage) * uint64(i)
+			inscription.Pointer = &pointer
+		}
+		batch.Inscriptions = append(batch.Inscriptions, &inscription)
+	}
+
+	commit, err := NewCommit(key, params, batch.Inscriptions...)
+	if err != nil {
+		return nil, err
+	}
+	batch.Commit = commit
+	return batch, nil
+}
+
+// CommitAmount returns the value the commit output needs to pay for the reveal outputs and fee at feeRate sat/vB.
+func (b *Batch) CommitAmount(feeRate parser.FeeRate) int64 {
+	return b.Commit.CommitAmount(feeRate, b.Outputs...)
+}
+
+// NewRevealTx returns the unsigned reveal transaction of the batch, see Commit.SignRevealTx to sign it.
+func (b *Batch) NewRevealTx(commitOutPoint wire.OutPoint, commitAmount int64,
+	feeRate parser.FeeRate) (*wire.MsgTx, error) {
+	return b.Commit.NewRevealTx(commitOutPoint, commitAmount, feeRate, b.Outputs...)
+}
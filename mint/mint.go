@@ -0,0 +1,178 @@
+// Package mint builds the commit and reveal transactions used to inscribe, fully offline.
+//
+// The commit output is a taproot output whose script tree has a single leaf: `<pubkey> OP_CHECKSIG` followed by the
+// inscription envelopes. Spending it through that leaf in the reveal transaction puts the envelopes on chain, where
+// parser.ParseInscriptionsFromTransaction finds them.
+package mint
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/balletcrypto/bitcoin-inscription-parser/parser"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/btcutil"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+const (
+	// DefaultPostage is the value of the output that receives the inscription, the same default as ord
+	DefaultPostage int64 = 10000
+	// RevealSequence signals RBF without enabling the relative lock time
+	RevealSequence uint32 = 0xfffffffd
+)
+
+var (
+	// ErrInsufficientCommitAmount is returned when the commit output can not pay for the reveal outputs and fee
+	ErrInsufficientCommitAmount = errors.New("commit amount does not cover the reveal outputs and fee")
+	// ErrCommitInputNotFound is returned when the reveal transaction does not spend the commit output
+	ErrCommitInputNotFound = errors.New("reveal transaction does not spend the commit output")
+)
+
+// Commit is the taproot output committing to the inscription script.
+type Commit struct {
+	// Script is the revealed tapscript: `<pubkey> OP_CHECKSIG` followed by the envelopes
+	Script       []byte
+	TapLeaf      txscript.TapLeaf
+	InternalKey  *btcec.PublicKey
+	OutputKey    *btcec.PublicKey
+	ControlBlock []byte
+	// PkScript is the script of the commit output
+	PkScript []byte
+	Address  *btcutil.AddressTaproot
+}
+
+// NewCommit builds the commit output for the inscriptions. The key is used both as the taproot internal key and as
+// the key of the reveal script, so the same private key signs the reveal transaction.
+func NewCommit(key *btcec.PublicKey, params *chaincfg.Params, inscriptions ...*parser.InscriptionContent) (*Commit, error) {
+	prefix, err := txscript.NewScriptBuilder().
+		AddData(schnorr.SerializePubKey(key)).
+		AddOp(txscript.OP_CHECKSIG).
+		Script()
+	if err != nil {
+		return nil, err
+	}
+	script, err := parser.BuildInscriptionScript(prefix, inscriptions...)
+	if err != nil {
+		return nil, fmt.Errorf("build inscription script: %w", err)
+	}
+	return NewCommitFromScript(key, script, params)
+}
+
+// NewCommitFromScript builds the commit output for an inscription script built by the caller.
+func NewCommitFromScript(key *btcec.PublicKey, script []byte, params *chaincfg.Params) (*Commit, error) {
+	tapLeaf := txscript.NewBaseTapLeaf(script)
+	tapScriptTree := txscript.AssembleTaprootScriptTree(tapLeaf)
+	merkleRoot := tapScriptTree.RootNode.TapHash()
+	outputKey := txscript.ComputeTaprootOutputKey(key, merkleRoot[:])
+
+	controlBlock := tapScriptTree.LeafMerkleProofs[0].ToControlBlock(key)
+	rawControlBlock, err := controlBlock.ToBytes()
+	if err != nil {
+		return nil, err
+	}
+
+	address, err := btcutil.NewAddressTaproot(schnorr.SerializePubKey(outputKey), params)
+	if err != nil {
+		return nil, err
+	}
+	pkScript, err := txscript.PayToAddrScript(address)
+	if err != nil {
+		return nil, err
+	}
+
+	return &Commit{
+		Script:       script,
+		TapLeaf:      tapLeaf,
+		InternalKey:  key,
+		OutputKey:    outputKey,
+		ControlBlock: rawControlBlock,
+		PkScript:     pkScript,
+		Address:      address,
+	}, nil
+}
+
+// RevealFee returns the fee of a reveal transaction with the given outputs at feeRate sat/vB, rounded up to a whole
+// sat.
+func (c *Commit) RevealFee(feeRate parser.FeeRate, outputs ...*wire.TxOut) int64 {
+	revealTx := c.newRevealTx(wire.OutPoint{}, outputs)
+	// A schnorr signature with the default sighash type is 64 bytes
+	revealTx.TxIn[0].Witness = wire.TxWitness{make([]byte, schnorr.SignatureSize), c.Script, c.ControlBlock}
+	return feeRate.Fee(VirtualSize(revealTx))
+}
+
+// CommitAmount returns the value the commit output needs to pay for the reveal outputs and fee.
+func (c *Commit) CommitAmount(feeRate parser.FeeRate, outputs ...*wire.TxOut) int64 {
+	amount := c.RevealFee(feeRate, outputs...)
+	for _, output := range outputs {
+		amount += output.Value
+	}
+	return amount
+}
+
+// TxOut returns the commit output with the given value, to be added to a commit transaction funded by the caller.
+func (c *Commit) TxOut(amount int64) *wire.TxOut {
+	return wire.NewTxOut(amount, c.PkScript)
+}
+
+// NewRevealTx returns the unsigned reveal transaction spending the commit output. The inscriptions land on the
+// first sat of the first output unless they have a pointer, so the first output is normally the destination with
+// the postage as value.
+func (c *Commit) NewRevealTx(commitOutPoint wire.OutPoint, commitAmount int64, feeRate parser.FeeRate,
+	outputs ...*wire.TxOut) (*wire.MsgTx, error) {
+	if required := c.CommitAmount(feeRate, outputs...); commitAmount < required {
+		return nil, fmt.Errorf("%w: %d < %d", ErrInsufficientCommitAmount, commitAmount, required)
+	}
+	return c.newRevealTx(commitOutPoint, outputs), nil
+}
+
+func (c *Commit) newRevealTx(commitOutPoint wire.OutPoint, outputs []*wire.TxOut) *wire.MsgTx {
+	revealTx := wire.NewMsgTx(2)
+	txIn := wire.NewTxIn(&commitOutPoint, nil, nil)
+	txIn.Sequence = RevealSequence
+	revealTx.AddTxIn(txIn)
+	for _, output := range outputs {
+		revealTx.AddTxOut(output)
+	}
+	return revealTx
+}
+
+// SignRevealTx signs the input of revealTx spending commitOutPoint through the inscription leaf, and sets its
+// witness to the signature, the script and the control block. Other inputs are left untouched, prevOuts must hold
+// the outputs spent by every input of the transaction.
+func (c *Commit) SignRevealTx(revealTx *wire.MsgTx, commitOutPoint wire.OutPoint, key *btcec.PrivateKey,
+	prevOuts map[wire.OutPoint]*wire.TxOut) error {
+	index := -1
+	for i, txIn := range revealTx.TxIn {
+		if txIn.PreviousOutPoint == commitOutPoint {
+			index = i
+			break
+		}
+	}
+	if index < 0 {
+		return ErrCommitInputNotFound
+	}
+	commitOutput, ok := prevOuts[commitOutPoint]
+	if !ok {
+		return fmt.Errorf("missing previous output %s", commitOutPoint)
+	}
+
+	prevOutFetcher := txscript.NewMultiPrevOutFetcher(prevOuts)
+	sigHashes := txscript.NewTxSigHashes(revealTx, prevOutFetcher)
+	signature, err := txscript.RawTxInTapscriptSignature(revealTx, sigHashes, index, commitOutput.Value,
+		commitOutput.PkScript, c.TapLeaf, txscript.SigHashDefault, key)
+	if err != nil {
+		return fmt.Errorf("sign reveal transaction: %w", err)
+	}
+	revealTx.TxIn[index].Witness = wire.TxWitness{signature, c.Script, c.ControlBlock}
+	return nil
+}
+
+// VirtualSize returns the virtual size of the transaction in vbytes, rounded up.
+func VirtualSize(msgTx *wire.MsgTx) int64 {
+	weight := int64(msgTx.SerializeSizeStripped()*(4-1) + msgTx.SerializeSize())
+	return (weight + 3) / 4
+}
@@ -0,0 +1,95 @@
+package parser
+
+import (
+	"runtime"
+	"sync"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+type BlockTransactionInscriptions struct {
+	TxID        chainhash.Hash
+	BlockHeight int32
+	// TxIndex is the position of the transaction in the block
+	TxIndex      int
+	Inscriptions []*TransactionInscription
+}
+
+type BlockInscriptions struct {
+	BlockHash   chainhash.Hash
+	BlockHeight int32
+	// Transactions only holds the transactions with inscriptions, in block order
+	Transactions []*BlockTransactionInscriptions
+	ByTxID       map[chainhash.Hash]*BlockTransactionInscriptions
+}
+
+// ParseInscriptionsFromBlock parses the inscriptions of every transaction in the block.
+func ParseInscriptionsFromBlock(msgBlock *wire.MsgBlock, height int32) *BlockInscriptions {
+	return ParseInscriptionsFromBlockWithWorkers(msgBlock, height, 1)
+}
+
+// ParseInscriptionsFromBlockWithWorkers parses the transactions of the block across the given number of goroutines,
+// a non-positive number uses one goroutine per CPU. The result is the same as ParseInscriptionsFromBlock whatever
+// the number of workers.
+func ParseInscriptionsFromBlockWithWorkers(msgBlock *wire.MsgBlock, height int32, workers int) *BlockInscriptions {
+	if workers <= 0 {
+		workers = runtime.NumCPU()
+	}
+	if workers > len(msgBlock.Transactions) {
+		workers = len(msgBlock.Transactions)
+	}
+
+	// Each transaction writes to its own slot, so the output order does not depend on scheduling
+	results := make([]*BlockTransactionInscriptions, len(msgBlock.Transactions))
+	parseTransaction := func(txIndex int) {
+		msgTx := msgBlock.Transactions[txIndex]
+		inscriptions := ParseInscriptionsFromTransaction(msgTx)
+		if len(inscriptions) == 0 {
+			return
+		}
+		results[txIndex] = &BlockTransactionInscriptions{
+			TxID:         inscriptions[0].InscriptionID.TxID,
+			BlockHeight:  height,
+			TxIndex:      txIndex,
+			Inscriptions: inscriptions,
+		}
+	}
+
+	if workers <= 1 {
+		for txIndex := range msgBlock.Transactions {
+			parseTransaction(txIndex)
+		}
+	} else {
+		var wg sync.WaitGroup
+		txIndexes := make(chan int)
+		for i := 0; i < workers; i++ {
+			wg.Add(1)
+			go func() {
+				defer wg.Done()
+				for txIndex := range txIndexes {
+					parseTransaction(txIndex)
+				}
+			}()
+		}
+		for txIndex := range msgBlock.Transactions {
+			txIndexes <- txIndex
+		}
+		close(txIndexes)
+		wg.Wait()
+	}
+
+	blockInscriptions := &BlockInscriptions{
+		BlockHash:   msgBlock.BlockHash(),
+		BlockHeight: height,
+		ByTxID:      make(map[chainhash.Hash]*BlockTransactionInscriptions),
+	}
+	for _, result := range results {
+		if result == nil {
+			continue
+		}
+		blockInscriptions.Transactions = append(blockInscriptions.Transactions, result)
+		blockInscriptions.ByTxID[result.TxID] = result
+	}
+	return blockInscriptions
+}
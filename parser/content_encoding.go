@@ -0,0 +1,66 @@
+package parser
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+
+	"github.com/andybalholm/brotli"
+)
+
+// DefaultMaxDecodedBodySize is the decompressed size limit used by DecodedBody. Compressed bodies are limited by the
+// block size, but a few megabytes of brotli data can expand to gigabytes.
+const DefaultMaxDecodedBodySize int64 = 16 << 20
+
+var (
+	// ErrUnsupportedContentEncoding is returned when the content encoding is not identity, br or gzip
+	ErrUnsupportedContentEncoding = errors.New("unsupported content encoding")
+	// ErrDecodedBodyTooLarge is returned when the decompressed body exceeds the size limit
+	ErrDecodedBodyTooLarge = errors.New("decoded body is too large")
+)
+
+// DecodedBody returns the content body with the content encoding removed, limited to DefaultMaxDecodedBodySize.
+func (i *InscriptionContent) DecodedBody() ([]byte, error) {
+	return i.DecodedBodyWithLimit(DefaultMaxDecodedBodySize)
+}
+
+// DecodedBodyWithLimit returns the content body with the content encoding removed. Like the HTTP header ord serves
+// it as, the content encoding may list several comma separated encodings, which are undone in reverse order. An
+// error wrapping ErrDecodedBodyTooLarge is returned as soon as any decoding step produces more than maxSize bytes.
+func (i *InscriptionContent) DecodedBodyWithLimit(maxSize int64) ([]byte, error) {
+	body := i.ContentBody
+	encodings := strings.Split(string(i.ContentEncoding), ",")
+	for j := len(encodings) - 1; j >= 0; j-- {
+		encoding := strings.ToLower(strings.TrimSpace(encodings[j]))
+
+		var reader io.Reader
+		switch encoding {
+		case "", "identity":
+			continue
+		case "br":
+			reader = brotli.NewReader(bytes.NewReader(body))
+		case "gzip", "x-gzip":
+			gzipReader, err := gzip.NewReader(bytes.NewReader(body))
+			if err != nil {
+				return nil, fmt.Errorf("decode gzip content: %w", err)
+			}
+			reader = gzipReader
+		default:
+			return nil, fmt.Errorf("%w: %q", ErrUnsupportedContentEncoding, encoding)
+		}
+
+		// Read one byte past the limit to tell a body of exactly maxSize bytes from a larger one
+		decoded, err := io.ReadAll(io.LimitReader(reader, maxSize+1))
+		if err != nil {
+			return nil, fmt.Errorf("decode %s content: %w", encoding, err)
+		}
+		if int64(len(decoded)) > maxSize {
+			return nil, fmt.Errorf("%w: more than %d bytes", ErrDecodedBodyTooLarge, maxSize)
+		}
+		body = decoded
+	}
+	return body, nil
+}
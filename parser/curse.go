@@ -0,0 +1,130 @@
+package parser
+
+import (
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+// Curse is a reason for ord to give an inscription a negative inscription number.
+type Curse int
+
+const (
+	CurseUnrecognizedEvenField Curse = iota
+	CurseDuplicateField
+	CurseIncompleteField
+	CurseNotInFirstInput
+	CurseNotAtOffsetZero
+	CursePointer
+	CursePushnum
+	CurseStutter
+	// CurseReinscription depends on the inscriptions already on the sat, so the parser never sets it. Indexers
+	// should add it with AddCurse.
+	CurseReinscription
+)
+
+var curseNames = map[Curse]string{
+	CurseUnrecognizedEvenField: "unrecognized_even_field",
+	CurseDuplicateField:        "duplicate_field",
+	CurseIncompleteField:       "incomplete_field",
+	CurseNotInFirstInput:       "not_in_first_input",
+	CurseNotAtOffsetZero:       "not_at_offset_zero",
+	CursePointer:               "pointer",
+	CursePushnum:               "pushnum",
+	CurseStutter:               "stutter",
+	CurseReinscription:         "reinscription",
+}
+
+func (c Curse) String() string {
+	if name, ok := curseNames[c]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+// Jubilee heights, from which ord no longer gives cursed inscriptions negative numbers.
+const (
+	MainNetJubileeHeight       int32 = 824544
+	TestNet3JubileeHeight      int32 = 2544192
+	SigNetJubileeHeight        int32 = 175392
+	RegressionNetJubileeHeight int32 = 110
+)
+
+// JubileeHeight returns the jubilee height of the given network, or -1 if it is unknown.
+func JubileeHeight(params *chaincfg.Params) int32 {
+	switch params.Net {
+	case chaincfg.MainNetParams.Net:
+		return MainNetJubileeHeight
+	case chaincfg.TestNet3Params.Net:
+		return TestNet3JubileeHeight
+	case chaincfg.SigNetParams.Net:
+		return SigNetJubileeHeight
+	case chaincfg.RegressionNetParams.Net:
+		return RegressionNetJubileeHeight
+	default:
+		return -1
+	}
+}
+
+// IsCursed reports whether the inscription has any curse, regardless of the height it was revealed at.
+func (t *TransactionInscription) IsCursed() bool {
+	return len(t.Curses) > 0
+}
+
+// IsCursedAt reports whether the inscription gets a negative inscription number when revealed at the given height.
+// Cursed inscriptions revealed at or after the jubilee are vindicated and numbered like blessed ones.
+func (t *TransactionInscription) IsCursedAt(height int32, params *chaincfg.Params) bool {
+	jubileeHeight := JubileeHeight(params)
+	if jubileeHeight >= 0 && height >= jubileeHeight {
+		return false
+	}
+	return t.IsCursed()
+}
+
+// IsVindicatedAt reports whether the inscription is cursed but revealed at or after the jubilee.
+func (t *TransactionInscription) IsVindicatedAt(height int32, params *chaincfg.Params) bool {
+	return t.IsCursed() && !t.IsCursedAt(height, params)
+}
+
+// AddCurse adds a curse the parser can not detect on its own, keeping the curses in ord's order of precedence.
+func (t *TransactionInscription) AddCurse(curse Curse) {
+	for i, c := range t.Curses {
+		if c == curse {
+			return
+		}
+		if c > curse {
+			t.Curses = append(t.Curses[:i], append([]Curse{curse}, t.Curses[i:]...)...)
+			return
+		}
+	}
+	t.Curses = append(t.Curses, curse)
+}
+
+// curses returns the curses that can be determined from the reveal transaction alone.
+func (t *TransactionInscription) curses() []Curse {
+	var curses []Curse
+	inscription := t.Inscription
+	if inscription.IsUnrecognizedEvenField {
+		curses = append(curses, CurseUnrecognizedEvenField)
+	}
+	if inscription.IsDuplicateField {
+		curses = append(curses, CurseDuplicateField)
+	}
+	if inscription.IsIncompleteField {
+		curses = append(curses, CurseIncompleteField)
+	}
+	if t.TxInIndex != 0 {
+		curses = append(curses, CurseNotInFirstInput)
+	}
+	if t.TxInOffset != 0 {
+		curses = append(curses, CurseNotAtOffsetZero)
+	}
+	if inscription.HasPointer {
+		curses = append(curses, CursePointer)
+	}
+	if inscription.IsPushnum {
+		curses = append(curses, CursePushnum)
+	}
+	if inscription.IsStutter {
+		curses = append(curses, CurseStutter)
+	}
+	return curses
+}
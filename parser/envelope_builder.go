@@ -0,0 +1,145 @@
+package parser
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/btcsuite/btcd/txscript"
+)
+
+// ErrInvalidRune is returned when the rune field does not fit in an unsigned 128-bit integer
+var ErrInvalidRune = errors.New("rune is not an unsigned 128-bit integer")
+
+// BuildInscriptionScript appends an envelope for each inscription to prefix, which is normally `<pubkey> OP_CHECKSIG`,
+// and returns the resulting tapscript. The prefix is not modified.
+func BuildInscriptionScript(prefix []byte, inscriptions ...*InscriptionContent) ([]byte, error) {
+	script := append([]byte{}, prefix...)
+	for _, inscription := range inscriptions {
+		var err error
+		script, err = AppendEnvelope(script, inscription)
+		if err != nil {
+			return nil, err
+		}
+	}
+	return script, nil
+}
+
+// AppendEnvelope appends the envelope of inscription to script. Fields are written in the same order as ord, the
+// metadata and the body are split into 520-byte pushes, and the body tag is only written if ContentBody is not nil.
+// Parsing the result with ParseInscriptions gives back the same fields, except that ContentLength is recomputed and
+// empty values are parsed as nil.
+//
+// Tags and small values are always written as data pushes, never as OP_1 - OP_16, so the inscription is not cursed
+// for using pushnum opcodes.
+func AppendEnvelope(script []byte, inscription *InscriptionContent) ([]byte, error) {
+	script = append(script, txscript.OP_FALSE, txscript.OP_IF)
+	script = appendPush(script, protocolID)
+
+	var err error
+	appendField := func(tag byte, value []byte) {
+		if err != nil || value == nil {
+			return
+		}
+		if len(value) > txscript.MaxScriptElementSize {
+			err = fmt.Errorf("%w: field %d is %d bytes", ErrPushTooLarge, tag, len(value))
+			return
+		}
+		script = appendPush(script, []byte{tag})
+		script = appendPush(script, value)
+	}
+
+	appendField(0x01, inscription.ContentType)
+	appendField(0x09, inscription.ContentEncoding)
+	appendField(0x07, inscription.Metaprotocol)
+	for _, parent := range inscription.Parents {
+		appendField(0x03, parent.Bytes())
+	}
+	if inscription.Delegate != nil {
+		appendField(0x0b, inscription.Delegate.Bytes())
+	}
+	if inscription.Pointer != nil {
+		appendField(0x02, encodePointer(*inscription.Pointer))
+	}
+	for _, chunk := range chunks(inscription.RawMetadata) {
+		appendField(0x05, chunk)
+	}
+	if inscription.Rune != nil {
+		value, runeErr := encodeRune(inscription.Rune)
+		if runeErr != nil {
+			return nil, runeErr
+		}
+		appendField(0x0d, value)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	if inscription.ContentBody != nil {
+		script = append(script, txscript.OP_0)
+		for _, chunk := range chunks(inscription.ContentBody) {
+			script = appendPush(script, chunk)
+		}
+	}
+
+	return append(script, txscript.OP_ENDIF), nil
+}
+
+// appendPush appends the minimal data push of data. Unlike txscript.ScriptBuilder it never turns single bytes into
+// OP_1 - OP_16, and it does not limit the script to the 10000 bytes of pre-taproot scripts.
+func appendPush(script []byte, data []byte) []byte {
+	switch n := len(data); {
+	case n == 0:
+		return append(script, txscript.OP_0)
+	case n < txscript.OP_PUSHDATA1:
+		script = append(script, byte(n))
+	case n <= 0xff:
+		script = append(script, txscript.OP_PUSHDATA1, byte(n))
+	case n <= 0xffff:
+		script = append(script, txscript.OP_PUSHDATA2, byte(n), byte(n>>8))
+	default:
+		script = append(script, txscript.OP_PUSHDATA4, byte(n), byte(n>>8), byte(n>>16), byte(n>>24))
+	}
+	return append(script, data...)
+}
+
+// chunks splits data into pushes of at most 520 bytes.
+func chunks(data []byte) [][]byte {
+	var result [][]byte
+	for len(data) > txscript.MaxScriptElementSize {
+		result = append(result, data[:txscript.MaxScriptElementSize])
+		data = data[txscript.MaxScriptElementSize:]
+	}
+	if len(data) > 0 {
+		result = append(result, data)
+	}
+	return result
+}
+
+// encodePointer encodes the pointer as a little-endian integer without trailing zero bytes, like ord.
+func encodePointer(pointer uint64) []byte {
+	value := make([]byte, 8)
+	binary.LittleEndian.PutUint64(value, pointer)
+	return trimTrailingZeros(value)
+}
+
+// encodeRune encodes the rune as a little-endian integer without trailing zero bytes, like ord.
+func encodeRune(runeValue *big.Int) ([]byte, error) {
+	if runeValue.Sign() < 0 || runeValue.BitLen() > 128 {
+		return nil, ErrInvalidRune
+	}
+	// Bytes is big-endian without leading zeros, so reversing it gives the trimmed little-endian encoding
+	value := append([]byte{}, runeValue.Bytes()...)
+	for i, j := 0, len(value)-1; i < j; i, j = i+1, j-1 {
+		value[i], value[j] = value[j], value[i]
+	}
+	return value, nil
+}
+
+func trimTrailingZeros(value []byte) []byte {
+	for len(value) > 0 && value[len(value)-1] == 0 {
+		value = value[:len(value)-1]
+	}
+	return value
+}
@@ -0,0 +1,51 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+)
+
+var (
+	// ErrMissingEndIf is returned when the script ends before the envelope is closed by OP_ENDIF
+	ErrMissingEndIf = errors.New("envelope is not terminated by OP_ENDIF")
+	// ErrInvalidPush is returned when a tag or field value is not a data push
+	ErrInvalidPush = errors.New("invalid data push in envelope fields")
+	// ErrOpcodeInBody is returned when the content body contains an opcode other than a data push
+	ErrOpcodeInBody = errors.New("non-push opcode in envelope body")
+	// ErrPushTooLarge is returned when a data push exceeds the 520-byte taproot limit
+	ErrPushTooLarge = errors.New("data push is larger than 520 bytes")
+	// ErrMalformedScript is returned when the script itself can not be tokenized
+	ErrMalformedScript = errors.New("malformed script")
+)
+
+// EnvelopeError describes why an envelope was rejected. It wraps one of the sentinel errors above, so callers can
+// test it with errors.Is.
+type EnvelopeError struct {
+	// TxInIndex is only set by ParseInscriptionsFromTransactionWithDiagnostics
+	TxInIndex uint32
+	// Offset is the byte offset in the witness script of the opcode that caused the rejection
+	Offset int32
+	// Err is the sentinel error describing the reason
+	Err error
+	// Cause is the underlying tokenizer error for ErrMalformedScript, nil otherwise
+	Cause error
+}
+
+func newEnvelopeError(err error, offset int32, cause error) *EnvelopeError {
+	return &EnvelopeError{
+		Offset: offset,
+		Err:    err,
+		Cause:  cause,
+	}
+}
+
+func (e *EnvelopeError) Error() string {
+	if e.Cause != nil {
+		return fmt.Sprintf("%v at script offset %d: %v", e.Err, e.Offset, e.Cause)
+	}
+	return fmt.Sprintf("%v at script offset %d", e.Err, e.Offset)
+}
+
+func (e *EnvelopeError) Unwrap() error {
+	return e.Err
+}
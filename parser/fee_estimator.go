@@ -0,0 +1,78 @@
+package parser
+
+import (
+	"math"
+
+	"github.com/btcsuite/btcd/btcec/v2/schnorr"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+const (
+	// P2TRPkScriptSize is the size of a pay-to-taproot output script
+	P2TRPkScriptSize = 34
+	// revealScriptPrefixSize is the size of `<32-byte pubkey> OP_CHECKSIG` preceding the envelopes
+	revealScriptPrefixSize = 1 + schnorr.PubKeyBytesLen + 1
+)
+
+// FeeRate is a fee rate in sat/vB, which may be fractional like 1.5 sat/vB.
+type FeeRate float64
+
+// Fee returns the fee of a transaction of vsize virtual bytes at the rate, rounded up to a whole sat like ord.
+func (r FeeRate) Fee(vsize int64) int64 {
+	return int64(math.Ceil(float64(r) * float64(vsize)))
+}
+
+// RevealEstimate is the size and cost of a reveal transaction with a single input spending the commit output through
+// the inscription leaf, and a single output receiving the inscription.
+type RevealEstimate struct {
+	// TapscriptSize is the size of `<pubkey> OP_CHECKSIG` followed by the envelope
+	TapscriptSize int
+	// WitnessWeight is the weight of the reveal input witness: signature, tapscript and control block
+	WitnessWeight int64
+	// RevealVSize is the virtual size of the reveal transaction
+	RevealVSize int64
+	// RevealFee is the fee of the reveal transaction at the requested fee rate
+	RevealFee int64
+	// CommitAmount is the value the commit output needs to pay for the postage and the reveal fee
+	CommitAmount int64
+}
+
+// EstimateReveal computes the exact size of the reveal transaction that inscribes the inscription, and its cost at
+// feeRate sat/vB with an output of postage sats and a script of destinationPkScriptSize bytes. The estimate assumes
+// a 64-byte schnorr signature with the default sighash type and a tapscript tree with a single leaf, which is how
+// the mint package builds reveal transactions.
+func EstimateReveal(inscription *InscriptionContent, feeRate FeeRate, postage int64,
+	destinationPkScriptSize int) (*RevealEstimate, error) {
+	envelope, err := AppendEnvelope(nil, inscription)
+	if err != nil {
+		return nil, err
+	}
+	tapscriptSize := revealScriptPrefixSize + len(envelope)
+
+	// Witness items are the signature, the tapscript and the control block, each prefixed by its length
+	witnessSize := wire.VarIntSerializeSize(3) +
+		wire.VarIntSerializeSize(schnorr.SignatureSize) + schnorr.SignatureSize +
+		wire.VarIntSerializeSize(uint64(tapscriptSize)) + tapscriptSize +
+		wire.VarIntSerializeSize(txscript.ControlBlockBaseSize) + txscript.ControlBlockBaseSize
+
+	// Version, input count, the input without signature script, output count, the output and lock time
+	baseSize := 4 +
+		wire.VarIntSerializeSize(1) + 36 + wire.VarIntSerializeSize(0) + 4 +
+		wire.VarIntSerializeSize(1) + 8 + wire.VarIntSerializeSize(uint64(destinationPkScriptSize)) +
+		destinationPkScriptSize +
+		4
+
+	// Witness data, including the segwit marker and flag, counts for one weight unit per byte, the rest for four
+	weight := int64(baseSize*4 + 2 + witnessSize)
+	vsize := (weight + 3) / 4
+	fee := feeRate.Fee(vsize)
+
+	return &RevealEstimate{
+		TapscriptSize: tapscriptSize,
+		WitnessWeight: int64(witnessSize),
+		RevealVSize:   vsize,
+		RevealFee:     fee,
+		CommitAmount:  postage + fee,
+	}, nil
+}
@@ -0,0 +1,109 @@
+package parser
+
+import (
+	"encoding/binary"
+	"errors"
+	"fmt"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+)
+
+// InscriptionIDSize is the size of an inscription ID in its fixed-length binary encoding: 32-byte txid followed by a
+// 4-byte little-endian index.
+const InscriptionIDSize = chainhash.HashSize + 4
+
+// InscriptionID identifies an inscription by the txid of its reveal transaction and its index within that transaction.
+type InscriptionID struct {
+	TxID  chainhash.Hash
+	Index uint32
+}
+
+// NewInscriptionIDFromStr parses an inscription ID in the <txid>i<index> form used by ord.
+func NewInscriptionIDFromStr(s string) (*InscriptionID, error) {
+	separator := strings.LastIndexByte(s, 'i')
+	if separator < 0 {
+		return nil, fmt.Errorf("invalid inscription ID %q: missing 'i' separator", s)
+	}
+	txID, err := chainhash.NewHashFromStr(s[:separator])
+	if err != nil || len(s[:separator]) != chainhash.MaxHashStringSize {
+		return nil, fmt.Errorf("invalid inscription ID %q: invalid txid", s)
+	}
+	index, err := strconv.ParseUint(s[separator+1:], 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid inscription ID %q: invalid index", s)
+	}
+	return &InscriptionID{
+		TxID:  *txID,
+		Index: uint32(index),
+	}, nil
+}
+
+// NewInscriptionIDFromBytes decodes an inscription ID the way it is stored in the parent and delegate tags: a 32-byte
+// txid followed by an optional little-endian index of up to 4 bytes. Like ord, a shorter index must not have trailing
+// zeros, while the full 4-byte index of the fixed 36-byte form may.
+func NewInscriptionIDFromBytes(value []byte) (*InscriptionID, error) {
+	if len(value) < chainhash.HashSize || len(value) > InscriptionIDSize {
+		return nil, fmt.Errorf("invalid inscription ID length %d", len(value))
+	}
+	index := value[chainhash.HashSize:]
+	if len(index) > 0 && len(index) != 4 && index[len(index)-1] == 0 {
+		return nil, errors.New("invalid inscription ID: index has trailing zero bytes")
+	}
+	var buf [4]byte
+	copy(buf[:], index)
+	inscriptionID := &InscriptionID{
+		Index: binary.LittleEndian.Uint32(buf[:]),
+	}
+	copy(inscriptionID.TxID[:], value[:chainhash.HashSize])
+	return inscriptionID, nil
+}
+
+// String returns the inscription ID in the <txid>i<index> form.
+func (id InscriptionID) String() string {
+	return id.TxID.String() + "i" + strconv.FormatUint(uint64(id.Index), 10)
+}
+
+// Bytes returns the compact encoding used by the parent and delegate tags, with trailing zero bytes of the index
+// removed.
+func (id InscriptionID) Bytes() []byte {
+	value, _ := id.MarshalBinary()
+	for len(value) > chainhash.HashSize && value[len(value)-1] == 0 {
+		value = value[:len(value)-1]
+	}
+	return value
+}
+
+// MarshalBinary returns the fixed 36-byte encoding of the inscription ID.
+func (id InscriptionID) MarshalBinary() ([]byte, error) {
+	value := make([]byte, InscriptionIDSize)
+	copy(value, id.TxID[:])
+	binary.LittleEndian.PutUint32(value[chainhash.HashSize:], id.Index)
+	return value, nil
+}
+
+// UnmarshalBinary decodes the fixed 36-byte encoding produced by MarshalBinary.
+func (id *InscriptionID) UnmarshalBinary(value []byte) error {
+	if len(value) != InscriptionIDSize {
+		return fmt.Errorf("invalid inscription ID length %d", len(value))
+	}
+	copy(id.TxID[:], value[:chainhash.HashSize])
+	id.Index = binary.LittleEndian.Uint32(value[chainhash.HashSize:])
+	return nil
+}
+
+// MarshalText implements encoding.TextMarshaler, so inscription IDs are encoded as <txid>i<index> strings in JSON.
+func (id InscriptionID) MarshalText() ([]byte, error) {
+	return []byte(id.String()), nil
+}
+
+// UnmarshalText implements encoding.TextUnmarshaler.
+func (id *InscriptionID) UnmarshalText(text []byte) error {
+	inscriptionID, err := NewInscriptionIDFromStr(string(text))
+	if err != nil {
+		return err
+	}
+	*id = *inscriptionID
+	return nil
+}
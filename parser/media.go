@@ -0,0 +1,66 @@
+package parser
+
+import (
+	"mime"
+	"strings"
+)
+
+// DefaultFileExtension is used for content types without a known extension
+const DefaultFileExtension = "bin"
+
+// fileExtensions follows the media table of ord, keyed by the content type without parameters
+var fileExtensions = map[string]string{
+	"application/cbor":          "cbor",
+	"application/json":          "json",
+	"application/octet-stream":  "bin",
+	"application/pdf":           "pdf",
+	"application/pgp-signature": "asc",
+	"application/protobuf":      "binpb",
+	"application/x-javascript":  "js",
+	"application/yaml":          "yaml",
+	"audio/flac":                "flac",
+	"audio/mpeg":                "mp3",
+	"audio/wav":                 "wav",
+	"font/otf":                  "otf",
+	"font/ttf":                  "ttf",
+	"font/woff":                 "woff",
+	"font/woff2":                "woff2",
+	"image/apng":                "apng",
+	"image/avif":                "avif",
+	"image/gif":                 "gif",
+	"image/jpeg":                "jpg",
+	"image/jxl":                 "jxl",
+	"image/png":                 "png",
+	"image/svg+xml":             "svg",
+	"image/webp":                "webp",
+	"model/gltf+json":           "gltf",
+	"model/gltf-binary":         "glb",
+	"model/stl":                 "stl",
+	"text/css":                  "css",
+	"text/html":                 "html",
+	"text/javascript":           "js",
+	"text/markdown":             "md",
+	"text/plain":                "txt",
+	"text/x-python":             "py",
+	"video/mp4":                 "mp4",
+	"video/webm":                "webm",
+}
+
+// FileExtension returns the file extension for the content type of the inscription, without the leading dot.
+// Parameters such as charset are ignored, and DefaultFileExtension is returned for unknown or missing types.
+func (i *InscriptionContent) FileExtension() string {
+	return ContentTypeFileExtension(string(i.ContentType))
+}
+
+// ContentTypeFileExtension returns the file extension for a content type, see InscriptionContent.FileExtension.
+func ContentTypeFileExtension(contentType string) string {
+	mediaType, _, err := mime.ParseMediaType(contentType)
+	if err != nil {
+		// Keep what comes before the parameters of a content type that does not parse
+		mediaType = strings.ToLower(strings.TrimSpace(strings.Split(contentType, ";")[0]))
+	}
+	if extension, ok := fileExtensions[mediaType]; ok {
+		return extension
+	}
+	return DefaultFileExtension
+}
@@ -0,0 +1,32 @@
+package parser
+
+import (
+	"github.com/balletcrypto/bitcoin-inscription-parser/cbor"
+)
+
+// Metadata decodes the CBOR metadata with cbor.DefaultDecodeOptions. It returns nil if the inscription has no
+// metadata, see the cbor package for the types of the decoded value.
+func (i *InscriptionContent) Metadata() (interface{}, error) {
+	return i.MetadataWithOptions(cbor.DefaultDecodeOptions)
+}
+
+// MetadataWithOptions decodes the CBOR metadata with the given limits.
+func (i *InscriptionContent) MetadataWithOptions(options cbor.DecodeOptions) (interface{}, error) {
+	if i.RawMetadata == nil {
+		return nil, nil
+	}
+	return options.Decode(i.RawMetadata)
+}
+
+// MetadataJSON decodes the CBOR metadata and encodes it as JSON, see cbor.ToJSON. It returns nil if the inscription
+// has no metadata.
+func (i *InscriptionContent) MetadataJSON() ([]byte, error) {
+	if i.RawMetadata == nil {
+		return nil, nil
+	}
+	metadata, err := i.Metadata()
+	if err != nil {
+		return nil, err
+	}
+	return cbor.ToJSON(metadata)
+}
@@ -1,8 +1,12 @@
 package parser
 
 import (
-	"encoding/hex"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"math/big"
 
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
 	"github.com/btcsuite/btcd/txscript"
 	"github.com/btcsuite/btcd/wire"
 	log "github.com/sirupsen/logrus"
@@ -12,177 +16,415 @@ const (
 	ProtocolID     string = "6f7264"
 	BodyTag        string = "00"
 	ContentTypeTag string = "01"
+
+	PointerTag         string = "02"
+	ParentTag          string = "03"
+	MetadataTag        string = "05"
+	MetaprotocolTag    string = "07"
+	ContentEncodingTag string = "09"
+	DelegateTag        string = "0b"
+	RuneTag            string = "0d"
+)
+
+// The raw values of the tags above, so that pushed data can be compared without hex encoding it
+const (
+	contentTypeTag     = 0x01
+	pointerTag         = 0x02
+	parentTag          = 0x03
+	metadataTag        = 0x05
+	metaprotocolTag    = 0x07
+	contentEncodingTag = 0x09
+	delegateTag        = 0x0b
+	runeTag            = 0x0d
 )
 
+var protocolID = []byte("ord")
+
+// envelopeHeaders are the ways OP_FALSE OP_IF can be followed by a push of the protocol ID, ord does not require the
+// push to be minimal. The first one, 00 63 03, is the only one seen in practice.
+var envelopeHeaders = [][]byte{
+	{txscript.OP_FALSE, txscript.OP_IF, txscript.OP_DATA_3},
+	{txscript.OP_FALSE, txscript.OP_IF, txscript.OP_PUSHDATA1, 0x03},
+	{txscript.OP_FALSE, txscript.OP_IF, txscript.OP_PUSHDATA2, 0x03, 0x00},
+	{txscript.OP_FALSE, txscript.OP_IF, txscript.OP_PUSHDATA4, 0x03, 0x00, 0x00, 0x00},
+}
+
 type TransactionInscription struct {
 	Inscription *InscriptionContent
-	TxInIndex   uint32
-	TxInOffset  uint64
+	// InscriptionID index counts inscriptions across all inputs of the transaction, unlike TxInOffset
+	InscriptionID InscriptionID
+	TxInIndex     uint32
+	TxInOffset    uint64
+	// Curses lists every reason ord would curse this inscription, in ord's order of precedence
+	Curses []Curse
 }
 
 type InscriptionContent struct {
-	ContentType             []byte
-	ContentBody             []byte
-	ContentLength           uint64
+	ContentType     []byte
+	ContentBody     []byte
+	ContentLength   uint64
+	ContentEncoding []byte
+	Metaprotocol    []byte
+	// RawMetadata is the CBOR encoded metadata, with chunks concatenated, see Metadata to decode it
+	RawMetadata []byte
+	// Pointer is nil if the pointer field is absent or can not be decoded as a 64-bit integer
+	Pointer *uint64
+	// HasPointer is set when the pointer field is present, even if it can not be decoded. Like ord, the curse
+	// depends on the field and not on its value.
+	HasPointer bool
+	// Parents holds every valid parent inscription ID in script order
+	Parents []InscriptionID
+	// Delegate is nil if the field is absent or is not a valid inscription ID
+	Delegate *InscriptionID
+	// Rune is nil if the rune field is absent or is larger than 128 bits
+	Rune                    *big.Int
 	IsUnrecognizedEvenField bool
+	// IsDuplicateField is set when any tag appears more than once, only the first value is used for the
+	// non-chunked fields
+	IsDuplicateField bool
+	// IsIncompleteField is set when the last tag before OP_ENDIF has no value
+	IsIncompleteField bool
+	// IsPushnum is set when the envelope uses OP_1NEGATE or OP_1 - OP_16 instead of a data push
+	IsPushnum bool
+	// IsStutter is set when the envelope follows an OP_FALSE OP_FALSE OP_IF sequence
+	IsStutter bool
 }
 
 func ParseInscriptionsFromTransaction(msgTx *wire.MsgTx) []*TransactionInscription {
-	var inscriptionsFromTx []*TransactionInscription
-	txHash := msgTx.TxHash().String()
+	inscriptionsFromTx, _ := ParseInscriptionsFromTransactionWithDiagnostics(msgTx)
+	return inscriptionsFromTx
+}
+
+// ParseInscriptionsFromTransactionWithDiagnostics works like ParseInscriptionsFromTransaction, and also returns the
+// reason every rejected envelope was dropped, tagged with the index of the input it was found in.
+func ParseInscriptionsFromTransactionWithDiagnostics(msgTx *wire.MsgTx) ([]*TransactionInscription, []*EnvelopeError) {
+	var (
+		inscriptionsFromTx []*TransactionInscription
+		envelopeErrors     []*EnvelopeError
+		// Most transactions hold no envelope, the hash is only computed once one is found
+		txID   chainhash.Hash
+		txHash string
+	)
 
 	if !msgTx.HasWitness() {
-		log.Debugf("Tx: %s inputs does not contain witness data", txHash)
-		return nil
+		if log.IsLevelEnabled(log.DebugLevel) {
+			log.Debugf("Tx: %s inputs does not contain witness data", msgTx.TxHash())
+		}
+		return nil, nil
 	}
 
 	for i, v := range msgTx.TxIn {
 		index, input := i, v
-		if len(input.Witness) <= 1 {
-			log.Debugf("Tx: %s, the length of tx input witness data is %d", txHash, len(input.Witness))
+		// Skip the control block checks unless the script may hold an envelope
+		if script, _, _, ok := splitTapscriptWitness(input.Witness); !ok || !containsEnvelopeMarker(script) {
 			continue
 		}
-		if len(input.Witness) == 2 && input.Witness[len(input.Witness)-1][0] == txscript.TaprootAnnexTag {
-			log.Debugf("Tx: %s, tx witness contains Taproot Annex data but the length of tx input witness data is 2",
-				txHash)
-			continue
+		if txHash == "" {
+			txID = msgTx.TxHash()
+			txHash = txID.String()
 		}
-
-		// If Taproot Annex data exists, take the last element of the witness as the script data, otherwise,
-		// take the penultimate element of the witness as the script data
-		var witnessScript []byte
-		if input.Witness[len(input.Witness)-1][0] == txscript.TaprootAnnexTag {
-			witnessScript = input.Witness[len(input.Witness)-1]
-		} else {
-			witnessScript = input.Witness[len(input.Witness)-2]
+		tapscript, err := ExtractTapscript(input.Witness)
+		if err != nil {
+			log.Debugf("Tx: %s, input %d is not a tapscript spend: %v", txHash, index, err)
+			continue
 		}
 
 		// Parse script and get ordinals content
-		inscriptions := ParseInscriptions(witnessScript)
-		if len(inscriptions) == 0 {
-			continue
+		inscriptions, errs := ParseInscriptionsWithDiagnostics(tapscript.Script)
+		for _, err := range errs {
+			err.TxInIndex = uint32(index)
+			log.Debugf("Tx: %s, rejected envelope in input %d: %v", txHash, index, err)
 		}
+		envelopeErrors = append(envelopeErrors, errs...)
 		for i, v := range inscriptions {
 			txInOffset, inscription := i, v
-			inscriptionsFromTx = append(inscriptionsFromTx, &TransactionInscription{
+			transactionInscription := &TransactionInscription{
 				Inscription: inscription,
-				TxInIndex:   uint32(index),
-				TxInOffset:  uint64(txInOffset),
-			})
+				InscriptionID: InscriptionID{
+					TxID:  txID,
+					Index: uint32(len(inscriptionsFromTx)),
+				},
+				TxInIndex:  uint32(index),
+				TxInOffset: uint64(txInOffset),
+			}
+			transactionInscription.Curses = transactionInscription.curses()
+			inscriptionsFromTx = append(inscriptionsFromTx, transactionInscription)
 		}
 	}
-	return inscriptionsFromTx
+	return inscriptionsFromTx, envelopeErrors
 }
 
 func ParseInscriptions(witnessScript []byte) []*InscriptionContent {
+	inscriptions, _ := ParseInscriptionsWithDiagnostics(witnessScript)
+	return inscriptions
+}
+
+// ParseInscriptionsWithDiagnostics works like ParseInscriptions, and also returns an *EnvelopeError for every
+// envelope that was found but rejected, or for a malformed script that could not be scanned to the end. Scripts
+// without an envelope header are not tokenized at all, so they are never reported as malformed.
+func ParseInscriptionsWithDiagnostics(witnessScript []byte) ([]*InscriptionContent, []*EnvelopeError) {
 	var (
-		inscriptions []*InscriptionContent
+		inscriptions   []*InscriptionContent
+		envelopeErrors []*EnvelopeError
+		stuttered      bool
 	)
 
+	if !containsEnvelopeMarker(witnessScript) {
+		return nil, nil
+	}
+
 	// Parse inscription content from witness script
 	tokenizer := txscript.MakeScriptTokenizer(0, witnessScript)
 	for tokenizer.Next() {
 		// Check inscription envelop header: OP_FALSE(0x00), OP_IF(0x63), PROTOCOL_ID([0x6f, 0x72, 0x64])
-		if tokenizer.Opcode() == txscript.OP_FALSE {
-			if !tokenizer.Next() || tokenizer.Opcode() != txscript.OP_IF {
-				return inscriptions
-			}
-			if !tokenizer.Next() || hex.EncodeToString(tokenizer.Data()) != ProtocolID {
-				return inscriptions
-			}
-			inscription := parseOneInscription(&tokenizer)
-			if inscription != nil {
-				inscriptions = append(inscriptions, inscription)
-			}
+		if tokenizer.Opcode() != txscript.OP_FALSE {
+			continue
+		}
+		// The header opcodes are only consumed if they match, so that an OP_FALSE following this one can start
+		// an envelope
+		if !accept(&tokenizer, func(t *txscript.ScriptTokenizer) bool { return t.Opcode() == txscript.OP_IF }) ||
+			!accept(&tokenizer, func(t *txscript.ScriptTokenizer) bool {
+				return bytes.Equal(t.Data(), protocolID)
+			}) {
+			// Like ord, the next envelope is marked as stuttering if this failed header is followed by OP_FALSE
+			peek := tokenizer
+			stuttered = accept(&peek, func(t *txscript.ScriptTokenizer) bool { return t.Opcode() == txscript.OP_FALSE })
+			continue
 		}
+		inscription, err := parseOneInscription(&tokenizer)
+		if err != nil {
+			// Like ord, an envelope that fails after its header resets the stutter state
+			stuttered = false
+			envelopeErrors = append(envelopeErrors, err)
+			continue
+		}
+		inscription.IsStutter = stuttered
+		inscriptions = append(inscriptions, inscription)
 	}
 
-	return inscriptions
+	// A malformed script that failed inside an envelope has already been reported
+	if err := tokenizer.Err(); err != nil {
+		if len(envelopeErrors) == 0 || !errors.Is(envelopeErrors[len(envelopeErrors)-1], ErrMalformedScript) {
+			envelopeErrors = append(envelopeErrors, newEnvelopeError(ErrMalformedScript, tokenizer.ByteIndex(), err))
+		}
+	}
+
+	return inscriptions, envelopeErrors
 }
 
-func parseOneInscription(tokenizer *txscript.ScriptTokenizer) *InscriptionContent {
+func parseOneInscription(tokenizer *txscript.ScriptTokenizer) (*InscriptionContent, *EnvelopeError) {
 	var (
-		tags                    = make(map[string][]byte)
-		contentType             []byte
-		contentBody             []byte
-		contentLength           uint64
-		isUnrecognizedEvenField bool
+		// Each tag keeps every value pushed for it, in script order
+		tags              = make(map[string][][]byte)
+		contentBody       []byte
+		contentLength     uint64
+		isIncompleteField bool
+		isPushnum         bool
+		// Byte offset of the opcode the tokenizer is positioned on
+		offset int32
 	)
+	next := func() bool {
+		offset = tokenizer.ByteIndex()
+		return tokenizer.Next()
+	}
 
-	// Find any pushed data in the script. This includes OP_0, but not OP_1 - OP_16.
-	for tokenizer.Next() {
+	// Find any pushed data in the script. This includes OP_0, and OP_1NEGATE, OP_1 - OP_16 as pushnum opcodes.
+	for next() {
 		if tokenizer.Opcode() == txscript.OP_ENDIF {
 			break
-		} else if hex.EncodeToString([]byte{tokenizer.Opcode()}) == BodyTag {
+		} else if tokenizer.Opcode() == txscript.OP_0 {
 			var body []byte
-			for tokenizer.Next() {
+			for next() {
 				if tokenizer.Opcode() == txscript.OP_ENDIF {
 					break
-				} else if tokenizer.Opcode() == txscript.OP_0 {
-					// OP_0 push no data
-					continue
-				} else if tokenizer.Opcode() >= txscript.OP_DATA_1 && tokenizer.Opcode() <= txscript.OP_PUSHDATA4 {
-					// Taproot's restriction, individual data pushes may not be larger than 520 bytes.
-					if len(tokenizer.Data()) > 520 {
-						log.Errorf("data is longer than 520")
-						return nil
-					}
-					body = append(body, tokenizer.Data()...)
-				} else {
+				}
+				data, isPush, isPushnumOpcode := pushData(tokenizer.Opcode(), tokenizer.Data())
+				if !isPush {
 					// Invalid opcode found in content body, e.g., 615a7c90df1d4fdd07c6ea98766bc6846dd5264a9fa81ca41611bbf9bde38cf8.
-					return nil
+					return nil, newEnvelopeError(ErrOpcodeInBody, offset, nil)
+				}
+				// Taproot's restriction, individual data pushes may not be larger than 520 bytes.
+				if len(data) > txscript.MaxScriptElementSize {
+					return nil, newEnvelopeError(ErrPushTooLarge, offset, nil)
 				}
+				isPushnum = isPushnum || isPushnumOpcode
+				body = append(body, data...)
 			}
 			contentBody = body
 			contentLength = uint64(len(body))
 			break
 		} else {
-			if tokenizer.Data() == nil {
-				return nil
-			}
-			tag := hex.EncodeToString(tokenizer.Data())
-			if _, ok := tags[tag]; ok {
-				return nil
+			data, isPush, isPushnumOpcode := pushData(tokenizer.Opcode(), tokenizer.Data())
+			if !isPush {
+				return nil, newEnvelopeError(ErrInvalidPush, offset, nil)
 			}
-			if tokenizer.Next() {
-				if tokenizer.Opcode() != txscript.OP_0 && tokenizer.Data() == nil {
+			isPushnum = isPushnum || isPushnumOpcode
+			tag := string(data)
+			if next() {
+				if tokenizer.Opcode() == txscript.OP_ENDIF {
+					// A tag without a value still leaves a valid inscription, but ord curses it
+					isIncompleteField = true
+					break
+				}
+				data, isPush, isPushnumOpcode := pushData(tokenizer.Opcode(), tokenizer.Data())
+				if !isPush {
 					// Invalid data length, e.g., 0b71bd09c848be66334c0cdaa32686e98dffa8a212af694f59165cdbb588e587
-					return nil
+					return nil, newEnvelopeError(ErrInvalidPush, offset, nil)
 				}
-				tags[tag] = tokenizer.Data()
+				isPushnum = isPushnum || isPushnumOpcode
+				tags[tag] = append(tags[tag], data)
 			}
 		}
 	}
 
-	// No OP_ENDIF
-	if tokenizer.Opcode() != txscript.OP_ENDIF {
-		return nil
-	}
-
 	// Error occurred
 	if err := tokenizer.Err(); err != nil {
-		return nil
+		return nil, newEnvelopeError(ErrMalformedScript, tokenizer.ByteIndex(), err)
+	}
+
+	// No OP_ENDIF
+	if tokenizer.Opcode() != txscript.OP_ENDIF {
+		return nil, newEnvelopeError(ErrMissingEndIf, tokenizer.ByteIndex(), nil)
 	}
 
 	// Get inscription content
+	inscription := newInscriptionContent(tags)
+	inscription.ContentBody = contentBody
+	inscription.ContentLength = contentLength
+	inscription.IsIncompleteField = isIncompleteField
+	inscription.IsPushnum = isPushnum
+
+	return inscription, nil
+}
+
+// newInscriptionContent decodes the envelope fields from the values pushed for each raw tag, in script order.
+func newInscriptionContent(tags map[string][][]byte) *InscriptionContent {
+	inscription := &InscriptionContent{}
 	for k := range tags {
 		key := k
-		if key == ContentTypeTag {
-			contentType = tags[ContentTypeTag]
-			continue
+		values := tags[key]
+		// Like ord, any repeated tag is reported as a duplicate field, even for the tags that allow it
+		if len(values) > 1 {
+			inscription.IsDuplicateField = true
 		}
-		// Unrecognized even tag
-		tag, _ := hex.DecodeString(key)
-		if len(tag) > 0 && int(tag[0])%2 == 0 {
-			isUnrecognizedEvenField = true
+		// Only single byte tags are recognized
+		tag := -1
+		if len(key) == 1 {
+			tag = int(key[0])
+		}
+		switch tag {
+		case contentTypeTag:
+			inscription.ContentType = values[0]
+		case contentEncodingTag:
+			inscription.ContentEncoding = values[0]
+		case metaprotocolTag:
+			inscription.Metaprotocol = values[0]
+		case metadataTag:
+			// Metadata may be split into several pushes to get around the 520-byte push limit
+			inscription.RawMetadata = bytes.Join(values, nil)
+		case pointerTag:
+			inscription.Pointer = decodePointer(values[0])
+			inscription.HasPointer = true
+			// ord only takes the first value of a non-chunked tag, a leftover value of an even tag is unrecognized
+			if len(values) > 1 {
+				inscription.IsUnrecognizedEvenField = true
+			}
+		case parentTag:
+			// An inscription may have multiple parents, invalid parent IDs are skipped
+			for _, value := range values {
+				if parent := decodeInscriptionID(value); parent != nil {
+					inscription.Parents = append(inscription.Parents, *parent)
+				}
+			}
+		case delegateTag:
+			inscription.Delegate = decodeInscriptionID(values[0])
+		case runeTag:
+			inscription.Rune = decodeRune(values[0])
+		default:
+			// Unrecognized even tag
+			if len(key) > 0 && key[0]%2 == 0 {
+				inscription.IsUnrecognizedEvenField = true
+			}
 		}
 	}
+	return inscription
+}
 
-	inscription := &InscriptionContent{
-		ContentType:             contentType,
-		ContentBody:             contentBody,
-		ContentLength:           contentLength,
-		IsUnrecognizedEvenField: isUnrecognizedEvenField,
+// containsEnvelopeMarker reports whether the script holds an envelope header, so that the many scripts that do not
+// can be skipped without tokenizing them. A match may still be inside another push, the tokenizer decides.
+func containsEnvelopeMarker(script []byte) bool {
+	for i := 0; i < len(script); {
+		j := bytes.Index(script[i:], protocolID)
+		if j < 0 {
+			return false
+		}
+		header := script[:i+j]
+		for _, envelopeHeader := range envelopeHeaders {
+			if bytes.HasSuffix(header, envelopeHeader) {
+				return true
+			}
+		}
+		i += j + 1
 	}
-	return inscription
+	return false
+}
+
+// pushData returns the data pushed by the current opcode, and whether the opcode is a push at all. Like ord,
+// OP_1NEGATE and OP_1 - OP_16 are accepted as pushes of their numeric value and reported as pushnum opcodes.
+func pushData(opcode byte, pushed []byte) (data []byte, isPush bool, isPushnum bool) {
+	switch {
+	case opcode <= txscript.OP_PUSHDATA4:
+		return pushed, true, false
+	case opcode == txscript.OP_1NEGATE:
+		return []byte{0x81}, true, true
+	case opcode >= txscript.OP_1 && opcode <= txscript.OP_16:
+		return []byte{opcode - txscript.OP_1 + 1}, true, true
+	default:
+		return nil, false, false
+	}
+}
+
+// accept advances the tokenizer past the next opcode only if it satisfies match.
+func accept(tokenizer *txscript.ScriptTokenizer, match func(*txscript.ScriptTokenizer) bool) bool {
+	peek := *tokenizer
+	if !peek.Next() || !match(&peek) {
+		return false
+	}
+	*tokenizer = peek
+	return true
+}
+
+// decodePointer decodes a little-endian pointer value. Trailing zero bytes are allowed, but any non-zero byte
+// beyond the eighth makes the pointer invalid.
+func decodePointer(value []byte) *uint64 {
+	for i := 8; i < len(value); i++ {
+		if value[i] != 0 {
+			return nil
+		}
+	}
+	var buf [8]byte
+	copy(buf[:], value)
+	pointer := binary.LittleEndian.Uint64(buf[:])
+	return &pointer
+}
+
+// decodeInscriptionID returns nil for values that are not valid inscription IDs, ord ignores such fields.
+func decodeInscriptionID(value []byte) *InscriptionID {
+	inscriptionID, err := NewInscriptionIDFromBytes(value)
+	if err != nil {
+		return nil
+	}
+	return inscriptionID
+}
+
+// decodeRune decodes a little-endian 128-bit rune value.
+func decodeRune(value []byte) *big.Int {
+	if len(value) > 16 {
+		return nil
+	}
+	buf := make([]byte, len(value))
+	for i := range value {
+		buf[len(value)-1-i] = value[i]
+	}
+	return new(big.Int).SetBytes(buf)
 }
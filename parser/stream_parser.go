@@ -0,0 +1,303 @@
+package parser
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+
+	"github.com/btcsuite/btcd/txscript"
+)
+
+// StreamedInscription is an envelope found by a StreamParser. The fields are fully decoded when it is returned, the
+// body is read from Body.
+type StreamedInscription struct {
+	// Inscription holds the envelope fields. ContentBody is always nil, ContentLength and IsPushnum only account for
+	// the body once Body has returned io.EOF.
+	Inscription *InscriptionContent
+	// Body streams the content body one data push at a time. It returns io.EOF at the closing OP_ENDIF, or an
+	// *EnvelopeError if the envelope turns out to be invalid, in which case ParseInscriptions would have dropped it.
+	Body io.Reader
+}
+
+// StreamParser reads inscription envelopes from a witness script without holding the script in memory, at most one
+// data push is buffered at a time. It finds the same envelopes as ParseInscriptions, except that pushes larger than
+// 520 bytes are rejected in the envelope fields too, as consensus already forbids them.
+type StreamParser struct {
+	r      *bufio.Reader
+	offset int64
+	// peeked is the next opcode when the header check had to look ahead
+	peeked    *streamOp
+	err       error
+	stuttered bool
+	body      *streamBody
+}
+
+// streamOp is a single opcode read from the script, with its data if it is a push.
+type streamOp struct {
+	opcode byte
+	data   []byte
+	offset int64
+	// tooLarge is set for pushes larger than 520 bytes, their data is skipped rather than read
+	tooLarge bool
+}
+
+func NewStreamParser(r io.Reader) *StreamParser {
+	return &StreamParser{
+		r: bufio.NewReader(r),
+	}
+}
+
+// Next returns the next envelope in the script, skipping whatever is left of the previous body. It returns io.EOF at
+// the end of the script, and an *EnvelopeError for an envelope that was rejected while reading its fields, after
+// which Next can be called again to carry on. Any other error comes from the underlying reader.
+func (p *StreamParser) Next() (*StreamedInscription, error) {
+	// A body that already failed has reported its error to the caller
+	if p.body != nil && p.body.err == nil {
+		_, err := io.Copy(io.Discard, p.body)
+		// A rejected body was the caller's to read, only errors that end the scan are reported here
+		var envelopeErr *EnvelopeError
+		if err != nil && (!errors.As(err, &envelopeErr) || errors.Is(err, ErrMalformedScript)) {
+			p.body = nil
+			return nil, err
+		}
+	}
+	p.body = nil
+
+	for {
+		op, err := p.next()
+		if err != nil {
+			return nil, err
+		}
+		// Check inscription envelop header: OP_FALSE(0x00), OP_IF(0x63), PROTOCOL_ID([0x6f, 0x72, 0x64])
+		if op.opcode != txscript.OP_FALSE {
+			continue
+		}
+		if !p.accept(func(op *streamOp) bool { return op.opcode == txscript.OP_IF }) ||
+			!p.accept(func(op *streamOp) bool { return bytes.Equal(op.data, protocolID) }) {
+			// Like ord, the next envelope is marked as stuttering if this failed header is followed by OP_FALSE
+			peek := p.peek()
+			p.stuttered = peek != nil && peek.opcode == txscript.OP_FALSE
+			continue
+		}
+		inscription, hasBody, err := p.readFields()
+		if err != nil {
+			// Like ord, an envelope that fails after its header resets the stutter state
+			p.stuttered = false
+			return nil, err
+		}
+		inscription.IsStutter = p.stuttered
+		p.body = &streamBody{parser: p, inscription: inscription}
+		if !hasBody {
+			p.body.err = io.EOF
+		}
+		return &StreamedInscription{
+			Inscription: inscription,
+			Body:        p.body,
+		}, nil
+	}
+}
+
+// readFields reads the envelope fields up to the body tag or OP_ENDIF, and reports whether a body follows.
+func (p *StreamParser) readFields() (*InscriptionContent, bool, error) {
+	var (
+		// Each tag keeps every value pushed for it, in script order, keyed by the raw tag
+		tags              = make(map[string][][]byte)
+		hasBody           bool
+		isIncompleteField bool
+		isPushnum         bool
+	)
+
+	for {
+		op, err := p.nextInEnvelope()
+		if err != nil {
+			return nil, false, err
+		}
+		if op.opcode == txscript.OP_ENDIF {
+			break
+		} else if op.opcode == txscript.OP_0 {
+			hasBody = true
+			break
+		}
+		data, isPush, isPushnumOpcode := pushData(op.opcode, op.data)
+		if !isPush {
+			return nil, false, newEnvelopeError(ErrInvalidPush, int32(op.offset), nil)
+		}
+		if op.tooLarge {
+			return nil, false, newEnvelopeError(ErrPushTooLarge, int32(op.offset), nil)
+		}
+		isPushnum = isPushnum || isPushnumOpcode
+		tag := string(data)
+
+		op, err = p.nextInEnvelope()
+		if err != nil {
+			return nil, false, err
+		}
+		if op.opcode == txscript.OP_ENDIF {
+			// A tag without a value still leaves a valid inscription, but ord curses it
+			isIncompleteField = true
+			break
+		}
+		data, isPush, isPushnumOpcode = pushData(op.opcode, op.data)
+		if !isPush {
+			return nil, false, newEnvelopeError(ErrInvalidPush, int32(op.offset), nil)
+		}
+		if op.tooLarge {
+			return nil, false, newEnvelopeError(ErrPushTooLarge, int32(op.offset), nil)
+		}
+		isPushnum = isPushnum || isPushnumOpcode
+		tags[tag] = append(tags[tag], data)
+	}
+
+	inscription := newInscriptionContent(tags)
+	inscription.IsIncompleteField = isIncompleteField
+	inscription.IsPushnum = isPushnum
+	return inscription, hasBody, nil
+}
+
+// nextInEnvelope works like next, but the end of the script is reported as a missing OP_ENDIF.
+func (p *StreamParser) nextInEnvelope() (*streamOp, error) {
+	op, err := p.next()
+	if err == io.EOF {
+		return nil, newEnvelopeError(ErrMissingEndIf, int32(p.offset), nil)
+	}
+	return op, err
+}
+
+// next consumes the next opcode. A malformed script is reported once, later calls return io.EOF.
+func (p *StreamParser) next() (*streamOp, error) {
+	op := p.peek()
+	if op == nil {
+		err := p.err
+		if errors.Is(err, ErrMalformedScript) {
+			p.err = io.EOF
+		}
+		return nil, err
+	}
+	p.peeked = nil
+	return op, nil
+}
+
+// peek returns the next opcode without consuming it, or nil once the script ends or can not be read.
+func (p *StreamParser) peek() *streamOp {
+	if p.peeked == nil && p.err == nil {
+		p.peeked, p.err = p.readOp()
+	}
+	return p.peeked
+}
+
+// accept consumes the next opcode only if it satisfies match.
+func (p *StreamParser) accept(match func(*streamOp) bool) bool {
+	if op := p.peek(); op != nil && match(op) {
+		p.peeked = nil
+		return true
+	}
+	return false
+}
+
+// readOp reads one opcode and its push data from the underlying reader.
+func (p *StreamParser) readOp() (*streamOp, error) {
+	op := &streamOp{offset: p.offset}
+	opcode, err := p.r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+	op.opcode = opcode
+	p.offset++
+
+	var size int64
+	switch {
+	case opcode >= txscript.OP_DATA_1 && opcode <= txscript.OP_DATA_75:
+		size = int64(opcode)
+	case opcode == txscript.OP_PUSHDATA1, opcode == txscript.OP_PUSHDATA2, opcode == txscript.OP_PUSHDATA4:
+		var prefix [4]byte
+		prefixSize := 1 << (opcode - txscript.OP_PUSHDATA1)
+		if err := p.read(op, prefix[:prefixSize]); err != nil {
+			return nil, err
+		}
+		size = int64(binary.LittleEndian.Uint32(prefix[:]))
+	default:
+		return op, nil
+	}
+
+	if size > txscript.MaxScriptElementSize {
+		op.tooLarge = true
+		n, err := io.CopyN(io.Discard, p.r, size)
+		p.offset += n
+		if err != nil {
+			return nil, p.malformed(op, err)
+		}
+		return op, nil
+	}
+	op.data = make([]byte, size)
+	if err := p.read(op, op.data); err != nil {
+		return nil, err
+	}
+	return op, nil
+}
+
+// read fills buf with the data of op.
+func (p *StreamParser) read(op *streamOp, buf []byte) error {
+	n, err := io.ReadFull(p.r, buf)
+	p.offset += int64(n)
+	if err != nil {
+		return p.malformed(op, err)
+	}
+	return nil
+}
+
+// malformed reports a script that ends in the middle of op as an *EnvelopeError, errors from the underlying reader
+// are returned as is.
+func (p *StreamParser) malformed(op *streamOp, err error) error {
+	if err == io.EOF || err == io.ErrUnexpectedEOF {
+		return newEnvelopeError(ErrMalformedScript, int32(op.offset), io.ErrUnexpectedEOF)
+	}
+	return err
+}
+
+// streamBody reads the body of the current envelope from the parser.
+type streamBody struct {
+	parser      *StreamParser
+	inscription *InscriptionContent
+	// chunk is what is left of the last data push
+	chunk []byte
+	err   error
+}
+
+func (b *streamBody) Read(buf []byte) (int, error) {
+	for len(b.chunk) == 0 {
+		if b.err != nil {
+			return 0, b.err
+		}
+		b.chunk, b.err = b.nextChunk()
+		if b.err != nil && b.err != io.EOF {
+			b.parser.stuttered = false
+		}
+	}
+	n := copy(buf, b.chunk)
+	b.chunk = b.chunk[n:]
+	return n, nil
+}
+
+// nextChunk returns the data of the next body push, and io.EOF once the envelope is closed.
+func (b *streamBody) nextChunk() ([]byte, error) {
+	op, err := b.parser.nextInEnvelope()
+	if err != nil {
+		return nil, err
+	}
+	if op.opcode == txscript.OP_ENDIF {
+		return nil, io.EOF
+	}
+	data, isPush, isPushnumOpcode := pushData(op.opcode, op.data)
+	if !isPush {
+		return nil, newEnvelopeError(ErrOpcodeInBody, int32(op.offset), nil)
+	}
+	// Taproot's restriction, individual data pushes may not be larger than 520 bytes.
+	if op.tooLarge {
+		return nil, newEnvelopeError(ErrPushTooLarge, int32(op.offset), nil)
+	}
+	b.inscription.IsPushnum = b.inscription.IsPushnum || isPushnumOpcode
+	b.inscription.ContentLength += uint64(len(data))
+	return data, nil
+}
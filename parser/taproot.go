@@ -0,0 +1,87 @@
+package parser
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+var (
+	// ErrNotScriptPathSpend is returned when a witness has too few elements to be a taproot script-path spend
+	ErrNotScriptPathSpend = errors.New("witness is not a taproot script-path spend")
+	// ErrInvalidControlBlock is returned when the last witness element is not a well formed control block
+	ErrInvalidControlBlock = errors.New("invalid taproot control block")
+)
+
+// Tapscript is the script revealed by a taproot script-path spend, together with the data used to commit to it.
+type Tapscript struct {
+	Script []byte
+	// LeafVersion is always txscript.BaseLeafVersion, other leaf versions are not tapscript
+	LeafVersion txscript.TapscriptLeafVersion
+	// RawControlBlock is the control block as it appears in the witness, see ParseControlBlock
+	RawControlBlock []byte
+	// Annex is nil if the witness has no annex
+	Annex []byte
+}
+
+// ExtractTapscript follows BIP 341 to find the tapscript of a taproot script-path spend: the annex is removed if
+// the last element starts with 0x50, then the last remaining element must look like a control block of leaf version
+// 0xc0 and the one before it is the script. The previous output is not known here, so a witness that only looks like
+// a script-path spend can not be told apart from a genuine one.
+//
+// Only the length and leaf version of the control block are checked, decoding its internal key is left to
+// ParseControlBlock so that scanning witnesses stays cheap. A spend with an invalid key is rejected by consensus.
+func ExtractTapscript(witness wire.TxWitness) (*Tapscript, error) {
+	script, rawControlBlock, annex, ok := splitTapscriptWitness(witness)
+	// A single remaining element is a key-path spend signature
+	if !ok {
+		return nil, ErrNotScriptPathSpend
+	}
+
+	size := len(rawControlBlock)
+	switch {
+	case size < txscript.ControlBlockBaseSize || size > txscript.ControlBlockMaxSize:
+		return nil, fmt.Errorf("%w: invalid length %d", ErrInvalidControlBlock, size)
+	case (size-txscript.ControlBlockBaseSize)%txscript.ControlBlockNodeSize != 0:
+		return nil, fmt.Errorf("%w: invalid merkle path length %d", ErrInvalidControlBlock,
+			size-txscript.ControlBlockBaseSize)
+	}
+	leafVersion := txscript.TapscriptLeafVersion(rawControlBlock[0] & txscript.TaprootLeafMask)
+	// Only tapscript leaves can hold envelopes, other leaf versions have undefined semantics
+	if leafVersion != txscript.BaseLeafVersion {
+		return nil, fmt.Errorf("%w: unknown leaf version %#x", ErrInvalidControlBlock, byte(leafVersion))
+	}
+
+	return &Tapscript{
+		Script:          script,
+		LeafVersion:     leafVersion,
+		RawControlBlock: rawControlBlock,
+		Annex:           annex,
+	}, nil
+}
+
+// ParseControlBlock decodes the control block, including its internal key, for callers that need more than the
+// script.
+func (t *Tapscript) ParseControlBlock() (*txscript.ControlBlock, error) {
+	controlBlock, err := txscript.ParseControlBlock(t.RawControlBlock)
+	if err != nil {
+		return nil, fmt.Errorf("%w: %v", ErrInvalidControlBlock, err)
+	}
+	return controlBlock, nil
+}
+
+// splitTapscriptWitness removes the annex and returns the elements that would be the script and the control block,
+// without checking the control block. ok is false if fewer than two elements are left.
+func splitTapscriptWitness(witness wire.TxWitness) (script, rawControlBlock, annex []byte, ok bool) {
+	// The annex can only be present if there are at least two witness elements
+	if len(witness) >= 2 && len(witness[len(witness)-1]) > 0 && witness[len(witness)-1][0] == txscript.TaprootAnnexTag {
+		annex = witness[len(witness)-1]
+		witness = witness[:len(witness)-1]
+	}
+	if len(witness) < 2 {
+		return nil, nil, nil, false
+	}
+	return witness[len(witness)-2], witness[len(witness)-1], annex, true
+}
@@ -0,0 +1,80 @@
+package runes
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"math/big"
+
+	"github.com/balletcrypto/bitcoin-inscription-parser/parser"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// Reasons for an etching to not create its rune
+var (
+	ErrNoEtching    = errors.New("runestone has no etching")
+	ErrReservedRune = errors.New("rune is reserved")
+	ErrRuneLocked   = errors.New("rune is not unlocked yet")
+	ErrNoCommitment = errors.New("no input commits to the rune")
+)
+
+// ValidateEtching checks that the etching of the runestone, deciphered from the transaction, creates its rune at the
+// given height: runes must be active, the rune must be unlocked and not reserved, and a data push in the tapscript of
+// an input must commit to it. The commitment is usually the rune field of an inscription envelope, whose inscription
+// is returned when there is one. Etchings without a rune name get a reserved rune and need no commitment.
+//
+// The previous outputs and the runes etched so far are not known here, so callers must also check that the
+// committing input spends a taproot output confirmed at least six blocks before the etching, and that the rune was
+// not already etched.
+func ValidateEtching(msgTx *wire.MsgTx, runestone *Runestone, height int32,
+	params *chaincfg.Params) (*parser.TransactionInscription, error) {
+	if runestone == nil || runestone.Etching == nil {
+		return nil, ErrNoEtching
+	}
+	if firstRuneHeight := FirstRuneHeight(params); height < firstRuneHeight {
+		return nil, fmt.Errorf("%w: runes are not active before height %d", ErrRuneLocked, firstRuneHeight)
+	}
+	runeValue := runestone.Etching.Rune
+	if runeValue == nil {
+		return nil, nil
+	}
+	if IsReserved(runeValue) {
+		return nil, fmt.Errorf("%w: %s", ErrReservedRune, FormatRune(runeValue, 0))
+	}
+	if minimum := MinimumAtHeight(height, params); runeValue.Cmp(minimum) < 0 {
+		return nil, fmt.Errorf("%w: %s below %s at height %d", ErrRuneLocked, FormatRune(runeValue, 0),
+			FormatRune(minimum, 0), height)
+	}
+	if _, ok := CommittingInput(msgTx, runeValue); !ok {
+		return nil, fmt.Errorf("%w: %s", ErrNoCommitment, FormatRune(runeValue, 0))
+	}
+
+	for _, inscription := range parser.ParseInscriptionsFromTransaction(msgTx) {
+		if envelopeRune := inscription.Inscription.Rune; envelopeRune != nil && envelopeRune.Cmp(runeValue) == 0 {
+			return inscription, nil
+		}
+	}
+	return nil, nil
+}
+
+// CommittingInput returns the index of the first input whose tapscript pushes the commitment of the rune, like ord
+// any push counts, whether it is in an inscription envelope or not.
+func CommittingInput(msgTx *wire.MsgTx, runeValue *big.Int) (int, bool) {
+	commitment := Commitment(runeValue)
+	for index, txIn := range msgTx.TxIn {
+		tapscript, err := parser.ExtractTapscript(txIn.Witness)
+		if err != nil {
+			continue
+		}
+		// A malformed script still counts the pushes before the error
+		tokenizer := txscript.MakeScriptTokenizer(0, tapscript.Script)
+		for tokenizer.Next() {
+			if tokenizer.Opcode() <= txscript.OP_PUSHDATA4 && bytes.Equal(tokenizer.Data(), commitment) {
+				return index, true
+			}
+		}
+	}
+	return 0, false
+}
@@ -0,0 +1,152 @@
+package runes
+
+import (
+	"errors"
+	"fmt"
+	"math/big"
+	"strings"
+
+	"github.com/btcsuite/btcd/chaincfg"
+)
+
+// ErrInvalidRuneName is returned by ParseRune for names that are not letters A to Z, optionally separated by spacers
+var ErrInvalidRuneName = errors.New("invalid rune name")
+
+// Heights from which runes can be etched, see FirstRuneHeight.
+const (
+	MainNetFirstRuneHeight  int32 = 840000
+	TestNet3FirstRuneHeight int32 = 2520000
+)
+
+// unlockInterval is the number of blocks after which names one letter shorter start to unlock, so that every length
+// unlocks within a halving epoch
+const unlockInterval = 210000 / 12
+
+// steps holds the first rune of each name length, steps[n] is n+1 times the letter A
+var steps = func() []*big.Int {
+	steps := []*big.Int{new(big.Int)}
+	for i := 1; i < 28; i++ {
+		step := new(big.Int).Add(steps[i-1], big.NewInt(1))
+		steps = append(steps, step.Mul(step, big.NewInt(26)))
+	}
+	return steps
+}()
+
+// reservedRune is the first reserved rune, AAAAAAAAAAAAAAAAAAAAAAAAAAA. Etchings without a rune get the reserved rune
+// derived from their rune ID.
+var reservedRune = steps[26]
+
+// FormatRune returns the name of the rune, with a • after each letter whose bit is set in spacers. Runes number names
+// in bijective base 26: A is 0, Z is 25 and AA is 26.
+func FormatRune(runeValue *big.Int, spacers uint32) string {
+	n := new(big.Int).Add(runeValue, big.NewInt(1))
+	var letters []byte
+	remainder := new(big.Int)
+	for n.Sign() > 0 {
+		n.Sub(n, big.NewInt(1))
+		n.DivMod(n, big.NewInt(26), remainder)
+		letters = append(letters, 'A'+byte(remainder.Int64()))
+	}
+
+	var name strings.Builder
+	for i := range letters {
+		name.WriteByte(letters[len(letters)-1-i])
+		if i < len(letters)-1 && spacers&(1<<i) != 0 {
+			name.WriteString("•")
+		}
+	}
+	return name.String()
+}
+
+// ParseRune parses a rune name, accepting • or . as spacers between letters.
+func ParseRune(name string) (runeValue *big.Int, spacers uint32, err error) {
+	runeValue = new(big.Int)
+	letters := 0
+	spaced := false
+	for _, c := range name {
+		switch {
+		case c >= 'A' && c <= 'Z':
+			if letters > 0 {
+				runeValue.Add(runeValue, big.NewInt(1))
+			}
+			runeValue.Mul(runeValue, big.NewInt(26))
+			runeValue.Add(runeValue, big.NewInt(int64(c-'A')))
+			letters++
+			spaced = false
+		case c == '•' || c == '.':
+			if letters == 0 || spaced {
+				return nil, 0, fmt.Errorf("%w %q: misplaced spacer", ErrInvalidRuneName, name)
+			}
+			spacers |= 1 << (letters - 1)
+			spaced = true
+		default:
+			return nil, 0, fmt.Errorf("%w %q: invalid character %q", ErrInvalidRuneName, name, c)
+		}
+	}
+	if letters == 0 || spaced {
+		return nil, 0, fmt.Errorf("%w %q", ErrInvalidRuneName, name)
+	}
+	if runeValue.Cmp(maxUint128) > 0 {
+		return nil, 0, fmt.Errorf("%w %q: larger than 128 bits", ErrInvalidRuneName, name)
+	}
+	return runeValue, spacers, nil
+}
+
+// Commitment returns the data an etching transaction must push in a tapscript to commit to the rune: the rune as a
+// little-endian integer without trailing zero bytes. It is also how the rune field of an inscription envelope is
+// encoded.
+func Commitment(runeValue *big.Int) []byte {
+	// Bytes is big-endian without leading zeros, so reversing it gives the trimmed little-endian encoding
+	commitment := append([]byte{}, runeValue.Bytes()...)
+	for i, j := 0, len(commitment)-1; i < j; i, j = i+1, j-1 {
+		commitment[i], commitment[j] = commitment[j], commitment[i]
+	}
+	return commitment
+}
+
+// IsReserved reports whether the rune is one of the reserved runes, which can not be etched by name.
+func IsReserved(runeValue *big.Int) bool {
+	return runeValue.Cmp(reservedRune) >= 0
+}
+
+// ReservedRune returns the rune given to an etching without a rune name.
+func ReservedRune(id RuneID) *big.Int {
+	offset := new(big.Int).Lsh(new(big.Int).SetUint64(id.Block), 32)
+	offset.Or(offset, new(big.Int).SetUint64(uint64(id.Tx)))
+	return offset.Add(offset, reservedRune)
+}
+
+// FirstRuneHeight returns the height from which runes can be etched on the given network, 0 for networks other than
+// mainnet and testnet3.
+func FirstRuneHeight(params *chaincfg.Params) int32 {
+	switch params.Net {
+	case chaincfg.MainNetParams.Net:
+		return MainNetFirstRuneHeight
+	case chaincfg.TestNet3Params.Net:
+		return TestNet3FirstRuneHeight
+	default:
+		return 0
+	}
+}
+
+// MinimumAtHeight returns the smallest rune that can be etched at the given height. Names of 13 letters are unlocked
+// at the first rune height, and the minimum then decreases steadily until every name is unlocked a halving epoch later.
+func MinimumAtHeight(height int32, params *chaincfg.Params) *big.Int {
+	offset := int64(height) + 1
+	start := int64(FirstRuneHeight(params))
+	if offset < start {
+		return new(big.Int).Set(steps[12])
+	}
+	if offset >= start+210000 {
+		return new(big.Int).Set(steps[0])
+	}
+
+	progress := offset - start
+	length := 12 - progress/unlockInterval
+	end, begin := steps[length-1], steps[length]
+	// begin - (begin - end) * (progress % unlockInterval) / unlockInterval
+	minimum := new(big.Int).Sub(begin, end)
+	minimum.Mul(minimum, big.NewInt(progress%unlockInterval))
+	minimum.Quo(minimum, big.NewInt(unlockInterval))
+	return minimum.Sub(begin, minimum)
+}
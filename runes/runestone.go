@@ -0,0 +1,563 @@
+package runes
+
+import (
+	"fmt"
+	"math/big"
+	"sort"
+	"strconv"
+	"strings"
+	"unicode/utf8"
+
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// MagicNumber is the opcode that follows OP_RETURN in the output script of a runestone
+const MagicNumber = txscript.OP_13
+
+// MaxDivisibility is the largest number of decimals of a rune
+const MaxDivisibility = 38
+
+// MaxSpacers is the largest spacers field of an etching, one bit between each pair of the 28 letters of a name
+const MaxSpacers uint32 = 1<<27 - 1
+
+// Tags of the fields of a runestone. Even tags that are not recognized make the runestone a cenotaph, odd ones are
+// ignored.
+const (
+	TagBody         uint64 = 0
+	TagFlags        uint64 = 2
+	TagRune         uint64 = 4
+	TagPremine      uint64 = 6
+	TagCap          uint64 = 8
+	TagAmount       uint64 = 10
+	TagHeightStart  uint64 = 12
+	TagHeightEnd    uint64 = 14
+	TagOffsetStart  uint64 = 16
+	TagOffsetEnd    uint64 = 18
+	TagMint         uint64 = 20
+	TagPointer      uint64 = 22
+	TagCenotaph     uint64 = 126
+	TagDivisibility uint64 = 1
+	TagSpacers      uint64 = 3
+	TagSymbol       uint64 = 5
+	TagNop          uint64 = 127
+)
+
+// Bits of the flags field.
+const (
+	FlagEtching uint = 0
+	FlagTerms   uint = 1
+	FlagTurbo   uint = 2
+)
+
+// Flaw is a reason for a runestone to be a cenotaph. The runes sent to a cenotaph are burned.
+type Flaw int
+
+const (
+	NoFlaw Flaw = iota
+	FlawEdictOutput
+	FlawEdictRuneID
+	FlawInvalidScript
+	FlawOpcode
+	FlawSupplyOverflow
+	FlawTrailingIntegers
+	FlawTruncatedField
+	FlawUnrecognizedEvenTag
+	FlawUnrecognizedFlag
+	FlawVarint
+)
+
+var flawNames = map[Flaw]string{
+	NoFlaw:                  "none",
+	FlawEdictOutput:         "edict_output",
+	FlawEdictRuneID:         "edict_rune_id",
+	FlawInvalidScript:       "invalid_script",
+	FlawOpcode:              "opcode",
+	FlawSupplyOverflow:      "supply_overflow",
+	FlawTrailingIntegers:    "trailing_integers",
+	FlawTruncatedField:      "truncated_field",
+	FlawUnrecognizedEvenTag: "unrecognized_even_tag",
+	FlawUnrecognizedFlag:    "unrecognized_flag",
+	FlawVarint:              "varint",
+}
+
+func (f Flaw) String() string {
+	if name, ok := flawNames[f]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+func (f Flaw) MarshalText() ([]byte, error) {
+	return []byte(f.String()), nil
+}
+
+// RuneID identifies a rune by the height of the block and the index of the transaction that etched it.
+type RuneID struct {
+	Block uint64
+	Tx    uint32
+}
+
+// NewRuneIDFromStr parses a rune ID in the <block>:<tx> form used by ord.
+func NewRuneIDFromStr(s string) (*RuneID, error) {
+	block, tx, ok := strings.Cut(s, ":")
+	if !ok {
+		return nil, fmt.Errorf("invalid rune ID %q: missing ':' separator", s)
+	}
+	blockValue, err := strconv.ParseUint(block, 10, 64)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rune ID %q: invalid block", s)
+	}
+	txValue, err := strconv.ParseUint(tx, 10, 32)
+	if err != nil {
+		return nil, fmt.Errorf("invalid rune ID %q: invalid tx", s)
+	}
+	return &RuneID{Block: blockValue, Tx: uint32(txValue)}, nil
+}
+
+func (id RuneID) String() string {
+	return fmt.Sprintf("%d:%d", id.Block, id.Tx)
+}
+
+func (id RuneID) less(other RuneID) bool {
+	return id.Block < other.Block || id.Block == other.Block && id.Tx < other.Tx
+}
+
+// next applies the delta of an edict to the ID, ok is false if it overflows or gives an invalid ID.
+func (id RuneID) next(block, tx *big.Int) (next RuneID, ok bool) {
+	if !block.IsUint64() || !tx.IsUint64() || tx.Uint64() > 0xffffffff {
+		return RuneID{}, false
+	}
+	next.Block = id.Block + block.Uint64()
+	if next.Block < id.Block {
+		return RuneID{}, false
+	}
+	next.Tx = uint32(tx.Uint64())
+	if block.Sign() == 0 {
+		next.Tx = id.Tx + next.Tx
+		if next.Tx < id.Tx {
+			return RuneID{}, false
+		}
+	}
+	// Block 0 only holds the genesis rune, at index 0
+	if next.Block == 0 && next.Tx > 0 {
+		return RuneID{}, false
+	}
+	return next, true
+}
+
+// Edict transfers runes to an output. An output equal to the number of outputs splits the amount between every
+// output that is not an OP_RETURN.
+type Edict struct {
+	ID     RuneID
+	Amount *big.Int
+	Output uint32
+}
+
+// Terms are the conditions of open mints of a rune. Heights are absolute, offsets are relative to the etching block,
+// starts are inclusive and ends exclusive.
+type Terms struct {
+	// Amount is the amount of runes of each mint
+	Amount *big.Int
+	// Cap is the number of mints
+	Cap         *big.Int
+	HeightStart *uint64
+	HeightEnd   *uint64
+	OffsetStart *uint64
+	OffsetEnd   *uint64
+}
+
+// Etching creates a rune. Fields are nil when the runestone does not set them.
+type Etching struct {
+	Divisibility *uint8
+	Premine      *big.Int
+	// Rune is nil for etchings of a reserved rune, see ReservedRune
+	Rune    *big.Int
+	Spacers *uint32
+	Symbol  *rune
+	Terms   *Terms
+	// Turbo opts in to future protocol changes
+	Turbo bool
+}
+
+// Supply returns the premine plus the cap times the amount of the terms, ok is false if it is larger than 128 bits.
+func (e *Etching) Supply() (supply *big.Int, ok bool) {
+	supply = new(big.Int)
+	if e.Terms != nil && e.Terms.Cap != nil && e.Terms.Amount != nil {
+		supply.Mul(e.Terms.Cap, e.Terms.Amount)
+	}
+	if e.Premine != nil {
+		supply.Add(supply, e.Premine)
+	}
+	return supply, supply.Cmp(maxUint128) <= 0
+}
+
+// Runestone is the runes protocol message of a transaction.
+//
+// A runestone with a flaw is a cenotaph: its edicts and pointer are dropped, and its etching only keeps the rune
+// name, so Etching is nil if the etching had no name.
+type Runestone struct {
+	Edicts  []Edict
+	Etching *Etching
+	Mint    *RuneID
+	// Pointer is the output receiving the runes not transferred by edicts
+	Pointer *uint32
+	Flaw    Flaw
+}
+
+// IsCenotaph reports whether the runestone has a flaw.
+func (r *Runestone) IsCenotaph() bool {
+	return r.Flaw != NoFlaw
+}
+
+// Decipher decodes the runestone of the transaction: the first output whose script starts with OP_RETURN OP_13,
+// followed by data pushes of unsigned LEB128 integers. It returns nil if the transaction has no runestone.
+func Decipher(msgTx *wire.MsgTx) *Runestone {
+	payload, flaw, ok := runestonePayload(msgTx)
+	if !ok {
+		return nil
+	}
+	if flaw != NoFlaw {
+		return &Runestone{Flaw: flaw}
+	}
+	integers, err := decodeIntegers(payload)
+	if err != nil {
+		return &Runestone{Flaw: FlawVarint}
+	}
+
+	message := newMessage(msgTx, integers)
+	flaw = message.flaw
+	flags := new(big.Int)
+	message.take(TagFlags, 1, func(values []*big.Int) bool {
+		flags.Set(values[0])
+		return true
+	})
+
+	var etching *Etching
+	if takeFlag(flags, FlagEtching) {
+		etching = &Etching{}
+		message.take(TagDivisibility, 1, func(values []*big.Int) bool {
+			if !values[0].IsUint64() || values[0].Uint64() > MaxDivisibility {
+				return false
+			}
+			divisibility := uint8(values[0].Uint64())
+			etching.Divisibility = &divisibility
+			return true
+		})
+		message.take(TagPremine, 1, func(values []*big.Int) bool {
+			etching.Premine = values[0]
+			return true
+		})
+		message.take(TagRune, 1, func(values []*big.Int) bool {
+			etching.Rune = values[0]
+			return true
+		})
+		message.take(TagSpacers, 1, func(values []*big.Int) bool {
+			if !values[0].IsUint64() || values[0].Uint64() > uint64(MaxSpacers) {
+				return false
+			}
+			spacers := uint32(values[0].Uint64())
+			etching.Spacers = &spacers
+			return true
+		})
+		message.take(TagSymbol, 1, func(values []*big.Int) bool {
+			value := values[0]
+			if !value.IsUint64() || value.Uint64() > utf8.MaxRune || !utf8.ValidRune(rune(value.Uint64())) {
+				return false
+			}
+			symbol := rune(value.Uint64())
+			etching.Symbol = &symbol
+			return true
+		})
+		if takeFlag(flags, FlagTerms) {
+			terms := &Terms{}
+			message.take(TagCap, 1, func(values []*big.Int) bool {
+				terms.Cap = values[0]
+				return true
+			})
+			terms.HeightStart = message.takeUint64(TagHeightStart)
+			terms.HeightEnd = message.takeUint64(TagHeightEnd)
+			message.take(TagAmount, 1, func(values []*big.Int) bool {
+				terms.Amount = values[0]
+				return true
+			})
+			terms.OffsetStart = message.takeUint64(TagOffsetStart)
+			terms.OffsetEnd = message.takeUint64(TagOffsetEnd)
+			etching.Terms = terms
+		}
+		etching.Turbo = takeFlag(flags, FlagTurbo)
+	}
+
+	var mint *RuneID
+	message.take(TagMint, 2, func(values []*big.Int) bool {
+		id, ok := RuneID{}.next(values[0], values[1])
+		if !ok {
+			return false
+		}
+		mint = &id
+		return true
+	})
+	var pointer *uint32
+	message.take(TagPointer, 1, func(values []*big.Int) bool {
+		if !values[0].IsUint64() || values[0].Uint64() >= uint64(len(msgTx.TxOut)) {
+			return false
+		}
+		output := uint32(values[0].Uint64())
+		pointer = &output
+		return true
+	})
+
+	if etching != nil {
+		if _, ok := etching.Supply(); !ok && flaw == NoFlaw {
+			flaw = FlawSupplyOverflow
+		}
+	}
+	if flags.Sign() != 0 && flaw == NoFlaw {
+		flaw = FlawUnrecognizedFlag
+	}
+	if message.hasEvenTag() && flaw == NoFlaw {
+		flaw = FlawUnrecognizedEvenTag
+	}
+
+	if flaw != NoFlaw {
+		runestone := &Runestone{Mint: mint, Flaw: flaw}
+		if etching != nil && etching.Rune != nil {
+			runestone.Etching = &Etching{Rune: etching.Rune}
+		}
+		return runestone
+	}
+	return &Runestone{
+		Edicts:  message.edicts,
+		Etching: etching,
+		Mint:    mint,
+		Pointer: pointer,
+	}
+}
+
+// runestonePayload concatenates the data pushes of the runestone output, ok is false if there is none. An opcode that
+// is not a data push or a malformed push makes the runestone a cenotaph.
+func runestonePayload(msgTx *wire.MsgTx) (payload []byte, flaw Flaw, ok bool) {
+	for _, txOut := range msgTx.TxOut {
+		script := txOut.PkScript
+		if len(script) < 2 || script[0] != txscript.OP_RETURN || script[1] != MagicNumber {
+			continue
+		}
+		payload = []byte{}
+		tokenizer := txscript.MakeScriptTokenizer(0, script[2:])
+		for tokenizer.Next() {
+			// OP_0 is an empty push, OP_1 - OP_16 and OP_1NEGATE are opcodes
+			if tokenizer.Opcode() > txscript.OP_PUSHDATA4 {
+				return nil, FlawOpcode, true
+			}
+			payload = append(payload, tokenizer.Data()...)
+		}
+		if tokenizer.Err() != nil {
+			return nil, FlawInvalidScript, true
+		}
+		return payload, NoFlaw, true
+	}
+	return nil, NoFlaw, false
+}
+
+// message holds the fields and edicts of a runestone before they are interpreted.
+type message struct {
+	flaw   Flaw
+	edicts []Edict
+	fields map[uint64][]*big.Int
+	// evenTag is set when a tag larger than 64 bits is even, such tags are never recognized
+	evenTag bool
+}
+
+func newMessage(msgTx *wire.MsgTx, integers []*big.Int) *message {
+	m := &message{fields: map[uint64][]*big.Int{}}
+	for i := 0; i < len(integers); i += 2 {
+		tag := integers[i]
+		if tag.Sign() == 0 {
+			m.parseEdicts(msgTx, integers[i+1:])
+			break
+		}
+		if i+1 >= len(integers) {
+			m.flaw = FlawTruncatedField
+			break
+		}
+		if !tag.IsUint64() {
+			m.evenTag = m.evenTag || tag.Bit(0) == 0
+			continue
+		}
+		m.fields[tag.Uint64()] = append(m.fields[tag.Uint64()], integers[i+1])
+	}
+	return m
+}
+
+// parseEdicts decodes the edicts following the body tag, each one is four integers: the block and tx deltas of the
+// rune ID from the previous edict, the amount and the output.
+func (m *message) parseEdicts(msgTx *wire.MsgTx, integers []*big.Int) {
+	var id RuneID
+	for ; len(integers) > 0; integers = integers[4:] {
+		if len(integers) < 4 {
+			m.flaw = FlawTrailingIntegers
+			return
+		}
+		next, ok := id.next(integers[0], integers[1])
+		if !ok {
+			m.flaw = FlawEdictRuneID
+			return
+		}
+		output := integers[3]
+		if !output.IsUint64() || output.Uint64() > uint64(len(msgTx.TxOut)) {
+			m.flaw = FlawEdictOutput
+			return
+		}
+		id = next
+		m.edicts = append(m.edicts, Edict{ID: id, Amount: integers[2], Output: uint32(output.Uint64())})
+	}
+}
+
+// take passes the first n values of the field to with, and removes them if it accepts them. A rejected value stays,
+// so that it makes the runestone a cenotaph if the tag is even.
+func (m *message) take(tag uint64, n int, with func(values []*big.Int) bool) {
+	values := m.fields[tag]
+	if len(values) < n || !with(values[:n]) {
+		return
+	}
+	if len(values) == n {
+		delete(m.fields, tag)
+		return
+	}
+	m.fields[tag] = values[n:]
+}
+
+func (m *message) takeUint64(tag uint64) *uint64 {
+	var value *uint64
+	m.take(tag, 1, func(values []*big.Int) bool {
+		if !values[0].IsUint64() {
+			return false
+		}
+		v := values[0].Uint64()
+		value = &v
+		return true
+	})
+	return value
+}
+
+func (m *message) hasEvenTag() bool {
+	if m.evenTag {
+		return true
+	}
+	for tag := range m.fields {
+		if tag%2 == 0 {
+			return true
+		}
+	}
+	return false
+}
+
+// takeFlag reports whether the flag is set and clears it.
+func takeFlag(flags *big.Int, flag uint) bool {
+	if flags.Bit(int(flag)) == 0 {
+		return false
+	}
+	flags.SetBit(flags, int(flag), 0)
+	return true
+}
+
+// Encipher returns the OP_RETURN output script of the runestone. Its flaw is ignored, and edicts are sorted by rune ID
+// to be delta encoded.
+func (r *Runestone) Encipher() []byte {
+	var payload []byte
+	field := func(tag uint64, value *big.Int) {
+		payload = appendVarint(payload, new(big.Int).SetUint64(tag))
+		payload = appendVarint(payload, value)
+	}
+	uint64Field := func(tag uint64, value *uint64) {
+		if value != nil {
+			field(tag, new(big.Int).SetUint64(*value))
+		}
+	}
+	bigField := func(tag uint64, value *big.Int) {
+		if value != nil {
+			field(tag, value)
+		}
+	}
+
+	if etching := r.Etching; etching != nil {
+		flags := new(big.Int).SetBit(new(big.Int), int(FlagEtching), 1)
+		if etching.Terms != nil {
+			flags.SetBit(flags, int(FlagTerms), 1)
+		}
+		if etching.Turbo {
+			flags.SetBit(flags, int(FlagTurbo), 1)
+		}
+		field(TagFlags, flags)
+		bigField(TagRune, etching.Rune)
+		if etching.Divisibility != nil {
+			field(TagDivisibility, big.NewInt(int64(*etching.Divisibility)))
+		}
+		if etching.Spacers != nil {
+			field(TagSpacers, big.NewInt(int64(*etching.Spacers)))
+		}
+		if etching.Symbol != nil {
+			field(TagSymbol, big.NewInt(int64(*etching.Symbol)))
+		}
+		bigField(TagPremine, etching.Premine)
+		if terms := etching.Terms; terms != nil {
+			bigField(TagAmount, terms.Amount)
+			bigField(TagCap, terms.Cap)
+			uint64Field(TagHeightStart, terms.HeightStart)
+			uint64Field(TagHeightEnd, terms.HeightEnd)
+			uint64Field(TagOffsetStart, terms.OffsetStart)
+			uint64Field(TagOffsetEnd, terms.OffsetEnd)
+		}
+	}
+	if r.Mint != nil {
+		field(TagMint, new(big.Int).SetUint64(r.Mint.Block))
+		field(TagMint, new(big.Int).SetUint64(uint64(r.Mint.Tx)))
+	}
+	if r.Pointer != nil {
+		field(TagPointer, new(big.Int).SetUint64(uint64(*r.Pointer)))
+	}
+
+	if len(r.Edicts) > 0 {
+		payload = appendVarint(payload, new(big.Int).SetUint64(TagBody))
+		edicts := append([]Edict{}, r.Edicts...)
+		sort.SliceStable(edicts, func(i, j int) bool {
+			return edicts[i].ID.less(edicts[j].ID)
+		})
+		var previous RuneID
+		for _, edict := range edicts {
+			block, tx := edict.ID.Block-previous.Block, uint64(edict.ID.Tx)
+			if block == 0 {
+				tx -= uint64(previous.Tx)
+			}
+			payload = appendVarint(payload, new(big.Int).SetUint64(block))
+			payload = appendVarint(payload, new(big.Int).SetUint64(tx))
+			payload = appendVarint(payload, edict.Amount)
+			payload = appendVarint(payload, new(big.Int).SetUint64(uint64(edict.Output)))
+			previous = edict.ID
+		}
+	}
+
+	script := []byte{txscript.OP_RETURN, MagicNumber}
+	for len(payload) > 0 {
+		chunk := payload
+		if len(chunk) > txscript.MaxScriptElementSize {
+			chunk = chunk[:txscript.MaxScriptElementSize]
+		}
+		script = appendPush(script, chunk)
+		payload = payload[len(chunk):]
+	}
+	return script
+}
+
+// appendPush appends a data push that is never turned into OP_1 - OP_16, which would make the runestone a cenotaph.
+func appendPush(script []byte, data []byte) []byte {
+	switch {
+	case len(data) < txscript.OP_PUSHDATA1:
+		script = append(script, byte(len(data)))
+	case len(data) <= 0xff:
+		script = append(script, txscript.OP_PUSHDATA1, byte(len(data)))
+	default:
+		script = append(script, txscript.OP_PUSHDATA2, byte(len(data)), byte(len(data)>>8))
+	}
+	return append(script, data...)
+}
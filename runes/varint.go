@@ -0,0 +1,62 @@
+package runes
+
+import (
+	"errors"
+	"math/big"
+)
+
+// maxVarintLength is the length of the longest varint, the last of its 19 bytes holds the top 2 bits of a 128-bit
+// integer
+const maxVarintLength = 19
+
+var (
+	errVarintOverlong     = errors.New("varint longer than 19 bytes")
+	errVarintOverflow     = errors.New("varint larger than 128 bits")
+	errVarintUnterminated = errors.New("varint unterminated")
+)
+
+// maxUint128 is the largest value of runestone integers
+var maxUint128 = new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1))
+
+// decodeVarint decodes the unsigned LEB128 integer at the start of buf, returning it and its length.
+func decodeVarint(buf []byte) (*big.Int, int, error) {
+	n := new(big.Int)
+	for i, b := range buf {
+		if i >= maxVarintLength {
+			return nil, 0, errVarintOverlong
+		}
+		value := uint64(b & 0x7f)
+		if i == maxVarintLength-1 && value&0x7c != 0 {
+			return nil, 0, errVarintOverflow
+		}
+		n.Or(n, new(big.Int).Lsh(new(big.Int).SetUint64(value), uint(7*i)))
+		if b&0x80 == 0 {
+			return n, i + 1, nil
+		}
+	}
+	return nil, 0, errVarintUnterminated
+}
+
+// appendVarint appends the unsigned LEB128 encoding of n.
+func appendVarint(buf []byte, n *big.Int) []byte {
+	n = new(big.Int).Set(n)
+	for n.BitLen() > 7 {
+		buf = append(buf, byte(n.Uint64()&0x7f)|0x80)
+		n.Rsh(n, 7)
+	}
+	return append(buf, byte(n.Uint64()))
+}
+
+// decodeIntegers decodes the varints of a runestone payload.
+func decodeIntegers(payload []byte) ([]*big.Int, error) {
+	var integers []*big.Int
+	for len(payload) > 0 {
+		n, length, err := decodeVarint(payload)
+		if err != nil {
+			return nil, err
+		}
+		integers = append(integers, n)
+		payload = payload[length:]
+	}
+	return integers, nil
+}
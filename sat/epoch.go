@@ -0,0 +1,72 @@
+package sat
+
+// Constants of ordinal theory. They follow mainnet on every network, like ord.
+const (
+	CoinValue uint64 = 100000000
+	// Supply is the number of sats that will ever be mined
+	Supply uint64 = 2099999997690000
+	// LastSat is the last sat that will ever be mined
+	LastSat Sat = Sat(Supply - 1)
+
+	SubsidyHalvingInterval int32 = 210000
+	DiffChangeInterval     int32 = 2016
+	// CycleEpochs is the number of halvings between two conjunctions of a halving and a difficulty adjustment
+	CycleEpochs uint32 = 6
+	// FirstPostSubsidy is the first epoch without subsidy
+	FirstPostSubsidy Epoch = 33
+)
+
+// Epoch is a halving epoch, the blocks of an epoch have the same subsidy.
+type Epoch uint32
+
+// startingSats holds the first sat of every epoch, up to and including FirstPostSubsidy
+var startingSats = func() [FirstPostSubsidy + 1]Sat {
+	var sats [FirstPostSubsidy + 1]Sat
+	for epoch := Epoch(1); epoch <= FirstPostSubsidy; epoch++ {
+		sats[epoch] = sats[epoch-1] + Sat((epoch-1).Subsidy()*uint64(SubsidyHalvingInterval))
+	}
+	return sats
+}()
+
+// EpochAt returns the epoch of the block at height.
+func EpochAt(height int32) Epoch {
+	return Epoch(height / SubsidyHalvingInterval)
+}
+
+// Subsidy returns the subsidy of each block of the epoch.
+func (e Epoch) Subsidy() uint64 {
+	if e >= FirstPostSubsidy {
+		return 0
+	}
+	return 50 * CoinValue >> e
+}
+
+// StartingSat returns the first sat mined in the epoch, for epochs without subsidy this is Supply.
+func (e Epoch) StartingSat() Sat {
+	if e >= FirstPostSubsidy {
+		return startingSats[FirstPostSubsidy]
+	}
+	return startingSats[e]
+}
+
+// StartingHeight returns the height of the first block of the epoch.
+func (e Epoch) StartingHeight() int32 {
+	return int32(e) * SubsidyHalvingInterval
+}
+
+// Subsidy returns the subsidy of the block at height.
+func Subsidy(height int32) uint64 {
+	return EpochAt(height).Subsidy()
+}
+
+// StartingSat returns the first sat created by the subsidy of the block at height.
+func StartingSat(height int32) Sat {
+	epoch := EpochAt(height)
+	return epoch.StartingSat() + Sat(uint64(height-epoch.StartingHeight())*epoch.Subsidy())
+}
+
+// SubsidyRange returns the sats created by the subsidy of the block at height, empty once there is no subsidy.
+func SubsidyRange(height int32) Range {
+	start := StartingSat(height)
+	return Range{Start: start, End: start + Sat(Subsidy(height))}
+}
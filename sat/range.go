@@ -0,0 +1,120 @@
+package sat
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/balletcrypto/bitcoin-inscription-parser/parser"
+	"github.com/btcsuite/btcd/wire"
+)
+
+var (
+	// ErrUnbound is returned by InscribedSat when ord does not inscribe the inscription on any sat
+	ErrUnbound = errors.New("inscription is unbound")
+	// ErrInputRanges is returned by InscribedSat when the ranges do not match the inputs of the transaction
+	ErrInputRanges = errors.New("input sat ranges do not match the transaction")
+)
+
+// Range is the half-open range of sats [Start, End).
+type Range struct {
+	Start Sat
+	End   Sat
+}
+
+// Size returns the number of sats in the range.
+func (r Range) Size() uint64 {
+	return uint64(r.End - r.Start)
+}
+
+func (r Range) String() string {
+	return fmt.Sprintf("[%d, %d)", r.Start, r.End)
+}
+
+// Size returns the number of sats in the ranges.
+func Size(ranges []Range) uint64 {
+	var size uint64
+	for _, r := range ranges {
+		size += r.Size()
+	}
+	return size
+}
+
+// SatAt returns the sat at the given offset into the ranges, ok is false when the offset is beyond them.
+func SatAt(ranges []Range, offset uint64) (sat Sat, ok bool) {
+	for _, r := range ranges {
+		if offset < r.Size() {
+			return r.Start + Sat(offset), true
+		}
+		offset -= r.Size()
+	}
+	return 0, false
+}
+
+// Assign transfers the sats of the input ranges, in order, to outputs of the given values, first in first out. The
+// sats left over are the fee of the transaction.
+//
+// The coinbase transaction takes the subsidy range of its block followed by the fees of the other transactions in
+// block order, whatever its outputs leave over is lost.
+func Assign(inputs []Range, values []uint64) (outputs [][]Range, fee []Range) {
+	remaining := append([]Range(nil), inputs...)
+	outputs = make([][]Range, len(values))
+	for i, value := range values {
+		for value > 0 && len(remaining) > 0 {
+			r := remaining[0]
+			if r.Size() > value {
+				// The range spans several outputs
+				outputs[i] = append(outputs[i], Range{Start: r.Start, End: r.Start + Sat(value)})
+				remaining[0].Start += Sat(value)
+				break
+			}
+			if r.Size() > 0 {
+				outputs[i] = append(outputs[i], r)
+			}
+			value -= r.Size()
+			remaining = remaining[1:]
+		}
+	}
+	for _, r := range remaining {
+		if r.Size() > 0 {
+			fee = append(fee, r)
+		}
+	}
+	return outputs, fee
+}
+
+// InscribedSat returns the sat the inscription was inscribed on, given the sat ranges of every input of its reveal
+// transaction in input order. Like ord, every envelope of an input is inscribed on the first sat of that input,
+// unless its pointer falls within the outputs of the transaction, in which case it is inscribed on the sat at the
+// pointer offset into the inputs. Inscriptions on an input without sats, or with an unrecognized even field, are
+// unbound and return ErrUnbound.
+func InscribedSat(msgTx *wire.MsgTx, inscription *parser.TransactionInscription, inputRanges [][]Range) (Sat, error) {
+	if len(inputRanges) != len(msgTx.TxIn) || int(inscription.TxInIndex) >= len(inputRanges) {
+		return 0, fmt.Errorf("%w: %d ranges for %d inputs", ErrInputRanges, len(inputRanges), len(msgTx.TxIn))
+	}
+	if Size(inputRanges[inscription.TxInIndex]) == 0 || inscription.Inscription.IsUnrecognizedEvenField {
+		return 0, ErrUnbound
+	}
+
+	var offset, totalOutputValue uint64
+	for _, ranges := range inputRanges[:inscription.TxInIndex] {
+		offset += Size(ranges)
+	}
+	for _, txOut := range msgTx.TxOut {
+		totalOutputValue += uint64(txOut.Value)
+	}
+	if pointer := inscription.Inscription.Pointer; pointer != nil && *pointer < totalOutputValue {
+		offset = *pointer
+	}
+
+	var ranges []Range
+	for _, input := range inputRanges {
+		ranges = append(ranges, input...)
+	}
+	sat, ok := SatAt(ranges, offset)
+	if !ok {
+		// A pointer within the outputs but beyond the inputs is only possible with ranges that do not match the
+		// input values
+		return 0, fmt.Errorf("%w: offset %d beyond the inputs", ErrInputRanges, offset)
+	}
+	return sat, nil
+}
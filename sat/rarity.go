@@ -0,0 +1,57 @@
+package sat
+
+// Rarity classifies sats by the events of the block they were mined in.
+type Rarity int
+
+const (
+	Common Rarity = iota
+	// Uncommon is the first sat of a block
+	Uncommon
+	// Rare is the first sat of a difficulty adjustment period
+	Rare
+	// Epic is the first sat of a halving epoch
+	Epic
+	// Legendary is the first sat of a cycle
+	Legendary
+	// Mythic is the first sat of the genesis block
+	Mythic
+)
+
+var rarityNames = map[Rarity]string{
+	Common:    "common",
+	Uncommon:  "uncommon",
+	Rare:      "rare",
+	Epic:      "epic",
+	Legendary: "legendary",
+	Mythic:    "mythic",
+}
+
+func (r Rarity) String() string {
+	if name, ok := rarityNames[r]; ok {
+		return name
+	}
+	return "unknown"
+}
+
+func (r Rarity) MarshalText() ([]byte, error) {
+	return []byte(r.String()), nil
+}
+
+// Rarity returns the rarity of the sat.
+func (s Sat) Rarity() Rarity {
+	degree := s.Degree()
+	switch {
+	case degree.Hour == 0 && degree.Minute == 0 && degree.Second == 0 && degree.Third == 0:
+		return Mythic
+	case degree.Minute == 0 && degree.Second == 0 && degree.Third == 0:
+		return Legendary
+	case degree.Minute == 0 && degree.Third == 0:
+		return Epic
+	case degree.Second == 0 && degree.Third == 0:
+		return Rare
+	case degree.Third == 0:
+		return Uncommon
+	default:
+		return Common
+	}
+}
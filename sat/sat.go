@@ -0,0 +1,176 @@
+// Package sat implements the primitives of ordinal theory: sat numbers, the sats created by each block, their
+// first in first out transfer, rarity, and the degree, decimal and name notations.
+package sat
+
+import (
+	"errors"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+)
+
+// ErrInvalidSat is returned when a sat can not be parsed or is beyond the supply
+var ErrInvalidSat = errors.New("invalid sat")
+
+// Sat is the ordinal number of a satoshi, in the order they were mined. Its methods expect a sat below Supply.
+type Sat uint64
+
+// Epoch returns the halving epoch the sat was mined in.
+func (s Sat) Epoch() Epoch {
+	// The first epoch whose starting sat is beyond s, minus one
+	return Epoch(sort.Search(len(startingSats), func(i int) bool {
+		return startingSats[i] > s
+	}) - 1)
+}
+
+// Height returns the height of the block that mined the sat.
+func (s Sat) Height() int32 {
+	epoch := s.Epoch()
+	return epoch.StartingHeight() + int32(uint64(s-epoch.StartingSat())/epoch.Subsidy())
+}
+
+// Third returns the position of the sat within the subsidy of its block.
+func (s Sat) Third() uint64 {
+	epoch := s.Epoch()
+	return uint64(s-epoch.StartingSat()) % epoch.Subsidy()
+}
+
+// Cycle returns the cycle of the sat, a cycle lasts CycleEpochs halvings.
+func (s Sat) Cycle() uint32 {
+	return uint32(s.Epoch()) / CycleEpochs
+}
+
+// Degree returns the sat in degree notation.
+func (s Sat) Degree() Degree {
+	height := s.Height()
+	return Degree{
+		Hour:   uint32(height / (int32(CycleEpochs) * SubsidyHalvingInterval)),
+		Minute: uint32(height % SubsidyHalvingInterval),
+		Second: uint32(height % DiffChangeInterval),
+		Third:  s.Third(),
+	}
+}
+
+// Decimal returns the sat in decimal notation, <height>.<third>.
+func (s Sat) Decimal() string {
+	return fmt.Sprintf("%d.%d", s.Height(), s.Third())
+}
+
+// Name returns the sat in name notation. Names get shorter as sats are mined, the last sat is "a".
+func (s Sat) Name() string {
+	var name []byte
+	for x := Supply - uint64(s); x > 0; x = (x - 1) / 26 {
+		name = append(name, 'a'+byte((x-1)%26))
+	}
+	for i, j := 0, len(name)-1; i < j; i, j = i+1, j-1 {
+		name[i], name[j] = name[j], name[i]
+	}
+	return string(name)
+}
+
+func (s Sat) String() string {
+	return strconv.FormatUint(uint64(s), 10)
+}
+
+// Degree is the degree notation of a sat, A°B′C″D‴: the cycle, the block in the halving epoch, the block in the
+// difficulty adjustment period, and the sat in the block.
+type Degree struct {
+	Hour   uint32
+	Minute uint32
+	Second uint32
+	Third  uint64
+}
+
+func (d Degree) String() string {
+	return fmt.Sprintf("%d°%d′%d″%d‴", d.Hour, d.Minute, d.Second, d.Third)
+}
+
+// ParseSat parses a sat given as an integer, or in degree, decimal or name notation.
+func ParseSat(s string) (Sat, error) {
+	switch {
+	case strings.ContainsRune(s, '°'):
+		return parseDegree(s)
+	case strings.ContainsRune(s, '.'):
+		return parseDecimal(s)
+	case s != "" && strings.Trim(s, "abcdefghijklmnopqrstuvwxyz") == "":
+		return parseName(s)
+	}
+	number, err := strconv.ParseUint(s, 10, 64)
+	if err != nil || number >= Supply {
+		return 0, fmt.Errorf("%w %q", ErrInvalidSat, s)
+	}
+	return Sat(number), nil
+}
+
+func parseName(s string) (Sat, error) {
+	var x uint64
+	for _, c := range s {
+		x = x*26 + uint64(c-'a') + 1
+		if x > Supply {
+			return 0, fmt.Errorf("%w %q: name out of range", ErrInvalidSat, s)
+		}
+	}
+	return Sat(Supply - x), nil
+}
+
+func parseDecimal(s string) (Sat, error) {
+	heightPart, thirdPart, _ := strings.Cut(s, ".")
+	height, err := strconv.ParseUint(heightPart, 10, 31)
+	if err != nil {
+		return 0, fmt.Errorf("%w %q: invalid height", ErrInvalidSat, s)
+	}
+	third, err := strconv.ParseUint(thirdPart, 10, 64)
+	if err != nil {
+		return 0, fmt.Errorf("%w %q: invalid offset", ErrInvalidSat, s)
+	}
+	if third >= Subsidy(int32(height)) {
+		return 0, fmt.Errorf("%w %q: offset beyond the block subsidy", ErrInvalidSat, s)
+	}
+	return StartingSat(int32(height)) + Sat(third), nil
+}
+
+func parseDegree(s string) (Sat, error) {
+	parts := strings.FieldsFunc(s, func(r rune) bool {
+		return r == '°' || r == '′' || r == '″' || r == '‴'
+	})
+	// The third is optional, a degree without it is the first sat of the block
+	if !strings.HasSuffix(s, "‴") {
+		parts = append(parts, "0")
+	}
+	if len(parts) != 4 || strings.Count(s, "°") != 1 || strings.Count(s, "′") != 1 || strings.Count(s, "″") != 1 {
+		return 0, fmt.Errorf("%w %q: expected A°B′C″D‴", ErrInvalidSat, s)
+	}
+	var values [4]uint64
+	for i, part := range parts {
+		value, err := strconv.ParseUint(part, 10, 64)
+		if err != nil {
+			return 0, fmt.Errorf("%w %q: invalid number %q", ErrInvalidSat, s, part)
+		}
+		values[i] = value
+	}
+	cycle, epochOffset, periodOffset, third := values[0], values[1], values[2], values[3]
+	if epochOffset >= uint64(SubsidyHalvingInterval) {
+		return 0, fmt.Errorf("%w %q: epoch offset beyond the halving interval", ErrInvalidSat, s)
+	}
+	if periodOffset >= uint64(DiffChangeInterval) {
+		return 0, fmt.Errorf("%w %q: period offset beyond the difficulty adjustment interval", ErrInvalidSat, s)
+	}
+
+	// Every halving shifts the period offset relative to the epoch offset by halvingIncrement blocks, which gives
+	// the epoch within the cycle
+	const halvingIncrement = uint64(SubsidyHalvingInterval % DiffChangeInterval)
+	relationship := periodOffset + uint64(SubsidyHalvingInterval)*uint64(CycleEpochs) - epochOffset
+	if relationship%halvingIncrement != 0 {
+		return 0, fmt.Errorf("%w %q: inconsistent epoch and period offsets", ErrInvalidSat, s)
+	}
+	epoch := cycle*uint64(CycleEpochs) + relationship%uint64(DiffChangeInterval)/halvingIncrement
+	if epoch >= uint64(FirstPostSubsidy) {
+		return 0, fmt.Errorf("%w %q: cycle out of range", ErrInvalidSat, s)
+	}
+	height := int32(epoch)*SubsidyHalvingInterval + int32(epochOffset)
+	if third >= Subsidy(height) {
+		return 0, fmt.Errorf("%w %q: third beyond the block subsidy", ErrInvalidSat, s)
+	}
+	return StartingSat(height) + Sat(third), nil
+}
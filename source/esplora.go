@@ -0,0 +1,102 @@
+package source
+
+import (
+	"bytes"
+	"encoding/hex"
+	"fmt"
+	"io"
+	"net/http"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// EsploraSource reads from an Esplora compatible REST API, such as blockstream.info or mempool.space.
+type EsploraSource struct {
+	baseURL string
+	client  *http.Client
+}
+
+var _ Source = (*EsploraSource)(nil)
+
+// NewEsploraSource returns a source for the API at baseURL, e.g. https://mempool.space/api. A nil client uses
+// http.DefaultClient.
+func NewEsploraSource(baseURL string, client *http.Client) *EsploraSource {
+	if client == nil {
+		client = http.DefaultClient
+	}
+	return &EsploraSource{
+		baseURL: strings.TrimSuffix(baseURL, "/"),
+		client:  client,
+	}
+}
+
+func (s *EsploraSource) GetTransaction(txID *chainhash.Hash) (*wire.MsgTx, error) {
+	body, err := s.get("/tx/" + txID.String() + "/hex")
+	if err != nil {
+		return nil, err
+	}
+	rawTx, err := hex.DecodeString(strings.TrimSpace(string(body)))
+	if err != nil {
+		return nil, fmt.Errorf("transaction %s: %w", txID, err)
+	}
+	msgTx := &wire.MsgTx{}
+	if err := msgTx.Deserialize(bytes.NewReader(rawTx)); err != nil {
+		return nil, fmt.Errorf("transaction %s: %w", txID, err)
+	}
+	return msgTx, nil
+}
+
+func (s *EsploraSource) GetBlock(blockHash *chainhash.Hash) (*wire.MsgBlock, error) {
+	body, err := s.get("/block/" + blockHash.String() + "/raw")
+	if err != nil {
+		return nil, err
+	}
+	msgBlock := &wire.MsgBlock{}
+	if err := msgBlock.Deserialize(bytes.NewReader(body)); err != nil {
+		return nil, fmt.Errorf("block %s: %w", blockHash, err)
+	}
+	return msgBlock, nil
+}
+
+func (s *EsploraSource) GetBlockHash(height int32) (*chainhash.Hash, error) {
+	body, err := s.get("/block-height/" + strconv.Itoa(int(height)))
+	if err != nil {
+		return nil, err
+	}
+	return chainhash.NewHashFromStr(strings.TrimSpace(string(body)))
+}
+
+func (s *EsploraSource) BestHeight() (int32, error) {
+	body, err := s.get("/blocks/tip/height")
+	if err != nil {
+		return 0, err
+	}
+	height, err := strconv.ParseInt(strings.TrimSpace(string(body)), 10, 32)
+	if err != nil {
+		return 0, fmt.Errorf("tip height: %w", err)
+	}
+	return int32(height), nil
+}
+
+func (s *EsploraSource) get(path string) ([]byte, error) {
+	resp, err := s.client.Get(s.baseURL + path)
+	if err != nil {
+		return nil, err
+	}
+	defer resp.Body.Close()
+
+	body, err := io.ReadAll(resp.Body)
+	if err != nil {
+		return nil, err
+	}
+	switch {
+	case resp.StatusCode == http.StatusNotFound:
+		return nil, fmt.Errorf("%s: %w", path, ErrNotFound)
+	case resp.StatusCode != http.StatusOK:
+		return nil, fmt.Errorf("%s: unexpected status %s: %s", path, resp.Status, bytes.TrimSpace(body))
+	}
+	return body, nil
+}
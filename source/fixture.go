@@ -0,0 +1,124 @@
+package source
+
+import (
+	"bytes"
+	"encoding/hex"
+	"errors"
+	"fmt"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// FixtureSource reads hex encoded fixtures from a directory, for offline tests:
+//
+//	<dir>/tx/<txid>.hex       a raw transaction
+//	<dir>/block/<height>.hex  a raw block of the best chain
+//
+// The transactions of the fixture blocks can also be looked up by ID. Either subdirectory may be missing.
+type FixtureSource struct {
+	dir     string
+	blocks  map[chainhash.Hash]*wire.MsgBlock
+	hashes  map[int32]chainhash.Hash
+	blockTx map[chainhash.Hash]*wire.MsgTx
+	best    int32
+}
+
+var _ Source = (*FixtureSource)(nil)
+
+// NewFixtureSource loads the blocks of dir, the transaction files are only read when they are requested.
+func NewFixtureSource(dir string) (*FixtureSource, error) {
+	s := &FixtureSource{
+		dir:     dir,
+		blocks:  make(map[chainhash.Hash]*wire.MsgBlock),
+		hashes:  make(map[int32]chainhash.Hash),
+		blockTx: make(map[chainhash.Hash]*wire.MsgTx),
+		best:    -1,
+	}
+	paths, err := filepath.Glob(filepath.Join(dir, "block", "*.hex"))
+	if err != nil {
+		return nil, err
+	}
+	for _, path := range paths {
+		height, err := strconv.ParseInt(strings.TrimSuffix(filepath.Base(path), ".hex"), 10, 32)
+		if err != nil {
+			return nil, fmt.Errorf("%s: file name is not a block height", path)
+		}
+		data, err := readHexFile(path)
+		if err != nil {
+			return nil, err
+		}
+		msgBlock := &wire.MsgBlock{}
+		if err := msgBlock.Deserialize(bytes.NewReader(data)); err != nil {
+			return nil, fmt.Errorf("%s: %w", path, err)
+		}
+		blockHash := msgBlock.BlockHash()
+		s.blocks[blockHash] = msgBlock
+		s.hashes[int32(height)] = blockHash
+		for _, msgTx := range msgBlock.Transactions {
+			s.blockTx[msgTx.TxHash()] = msgTx
+		}
+		if int32(height) > s.best {
+			s.best = int32(height)
+		}
+	}
+	return s, nil
+}
+
+func (s *FixtureSource) GetTransaction(txID *chainhash.Hash) (*wire.MsgTx, error) {
+	path := filepath.Join(s.dir, "tx", txID.String()+".hex")
+	data, err := readHexFile(path)
+	if errors.Is(err, os.ErrNotExist) {
+		if msgTx, ok := s.blockTx[*txID]; ok {
+			return msgTx, nil
+		}
+		return nil, fmt.Errorf("transaction %s: %w", txID, ErrNotFound)
+	} else if err != nil {
+		return nil, err
+	}
+	msgTx := &wire.MsgTx{}
+	if err := msgTx.Deserialize(bytes.NewReader(data)); err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return msgTx, nil
+}
+
+func (s *FixtureSource) GetBlock(blockHash *chainhash.Hash) (*wire.MsgBlock, error) {
+	msgBlock, ok := s.blocks[*blockHash]
+	if !ok {
+		return nil, fmt.Errorf("block %s: %w", blockHash, ErrNotFound)
+	}
+	return msgBlock, nil
+}
+
+func (s *FixtureSource) GetBlockHash(height int32) (*chainhash.Hash, error) {
+	blockHash, ok := s.hashes[height]
+	if !ok {
+		return nil, fmt.Errorf("block height %d: %w", height, ErrNotFound)
+	}
+	return &blockHash, nil
+}
+
+// BestHeight returns the highest block fixture, or ErrNotFound if there is none.
+func (s *FixtureSource) BestHeight() (int32, error) {
+	if s.best < 0 {
+		return 0, fmt.Errorf("best height: %w", ErrNotFound)
+	}
+	return s.best, nil
+}
+
+func readHexFile(path string) ([]byte, error) {
+	data, err := os.ReadFile(path)
+	if err != nil {
+		return nil, err
+	}
+	decoded, err := hex.DecodeString(strings.TrimSpace(string(data)))
+	if err != nil {
+		return nil, fmt.Errorf("%s: %w", path, err)
+	}
+	return decoded, nil
+}
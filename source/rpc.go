@@ -0,0 +1,68 @@
+package source
+
+import (
+	"errors"
+	"fmt"
+
+	"github.com/btcsuite/btcd/btcjson"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/rpcclient"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// RPCSource reads from a bitcoind node over JSON-RPC. Looking up transactions that are not in the mempool or a
+// wallet requires the node to run with -txindex.
+type RPCSource struct {
+	client *rpcclient.Client
+}
+
+var _ Source = (*RPCSource)(nil)
+
+// NewRPCSource wraps an RPC client, normally created with HTTPPostMode set for bitcoind.
+func NewRPCSource(client *rpcclient.Client) *RPCSource {
+	return &RPCSource{
+		client: client,
+	}
+}
+
+func (s *RPCSource) GetTransaction(txID *chainhash.Hash) (*wire.MsgTx, error) {
+	tx, err := s.client.GetRawTransaction(txID)
+	if err != nil {
+		return nil, rpcError(err, btcjson.ErrRPCInvalidAddressOrKey, "transaction %s", txID)
+	}
+	return tx.MsgTx(), nil
+}
+
+func (s *RPCSource) GetBlock(blockHash *chainhash.Hash) (*wire.MsgBlock, error) {
+	msgBlock, err := s.client.GetBlock(blockHash)
+	if err != nil {
+		return nil, rpcError(err, btcjson.ErrRPCInvalidAddressOrKey, "block %s", blockHash)
+	}
+	return msgBlock, nil
+}
+
+func (s *RPCSource) GetBlockHash(height int32) (*chainhash.Hash, error) {
+	blockHash, err := s.client.GetBlockHash(int64(height))
+	if err != nil {
+		// bitcoind reports a height above the tip as an invalid parameter
+		return nil, rpcError(err, btcjson.ErrRPCInvalidParameter, "block height %d", height)
+	}
+	return blockHash, nil
+}
+
+func (s *RPCSource) BestHeight() (int32, error) {
+	height, err := s.client.GetBlockCount()
+	if err != nil {
+		return 0, err
+	}
+	return int32(height), nil
+}
+
+// rpcError turns the error code bitcoind uses for a missing object into ErrNotFound.
+func rpcError(err error, notFound btcjson.RPCErrorCode, format string, args ...interface{}) error {
+	var rpcErr *btcjson.RPCError
+	if errors.As(err, &rpcErr) && rpcErr.Code == notFound {
+		return fmt.Errorf("%s: %w", fmt.Sprintf(format, args...), ErrNotFound)
+	}
+	return err
+}
@@ -0,0 +1,61 @@
+// Package source abstracts where transactions and blocks come from, so that the same parsing pipeline can run
+// against a bitcoind node, an Esplora compatible REST API, or a directory of fixtures.
+package source
+
+import (
+	"errors"
+
+	"github.com/balletcrypto/bitcoin-inscription-parser/parser"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// ErrNotFound is returned by every source when the requested transaction, block or height does not exist
+var ErrNotFound = errors.New("not found")
+
+// TxSource returns transactions by ID.
+type TxSource interface {
+	GetTransaction(txID *chainhash.Hash) (*wire.MsgTx, error)
+}
+
+// BlockSource returns blocks of the best chain.
+type BlockSource interface {
+	GetBlock(blockHash *chainhash.Hash) (*wire.MsgBlock, error)
+	// GetBlockHash returns the hash of the block at the given height of the best chain
+	GetBlockHash(height int32) (*chainhash.Hash, error)
+	// BestHeight returns the height of the tip of the best chain
+	BestHeight() (int32, error)
+}
+
+// Source is implemented by every backend of this package.
+type Source interface {
+	TxSource
+	BlockSource
+}
+
+// GetBlockByHeight returns the block at the given height of the best chain.
+func GetBlockByHeight(source BlockSource, height int32) (*wire.MsgBlock, error) {
+	blockHash, err := source.GetBlockHash(height)
+	if err != nil {
+		return nil, err
+	}
+	return source.GetBlock(blockHash)
+}
+
+// ParseTransaction fetches a transaction and parses its inscriptions.
+func ParseTransaction(source TxSource, txID *chainhash.Hash) ([]*parser.TransactionInscription, error) {
+	msgTx, err := source.GetTransaction(txID)
+	if err != nil {
+		return nil, err
+	}
+	return parser.ParseInscriptionsFromTransaction(msgTx), nil
+}
+
+// ParseBlock fetches the block at the given height and parses its inscriptions.
+func ParseBlock(source BlockSource, height int32) (*parser.BlockInscriptions, error) {
+	msgBlock, err := GetBlockByHeight(source, height)
+	if err != nil {
+		return nil, err
+	}
+	return parser.ParseInscriptionsFromBlock(msgBlock, height), nil
+}
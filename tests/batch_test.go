@@ -0,0 +1,152 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"testing"
+
+	"github.com/balletcrypto/bitcoin-inscription-parser/mint"
+	"github.com/balletcrypto/bitcoin-inscription-parser/parser"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+func testDestination(seed byte) []byte {
+	return append([]byte{txscript.OP_1, txscript.OP_DATA_32}, bytes.Repeat([]byte{seed}, 32)...)
+}
+
+// testPointerOutput returns the output and the offset within it of the sat a pointer refers to.
+func testPointerOutput(outputs []*wire.TxOut, pointer *uint64) (int, int64) {
+	var offset int64
+	if pointer != nil {
+		offset = int64(*pointer)
+	}
+	for i, output := range outputs {
+		if offset < output.Value {
+			return i, offset
+		}
+		offset -= output.Value
+	}
+	return -1, -1
+}
+
+func TestMintBatchReveal(t *testing.T) {
+	t.Parallel()
+
+	privateKey := testPrivateKey(0x03)
+	var inscriptions []*parser.InscriptionContent
+	for i := 0; i < 3; i++ {
+		inscriptions = append(inscriptions, &parser.InscriptionContent{
+			ContentType: []byte("text/plain;charset=utf-8"),
+			ContentBody: []byte(fmt.Sprintf("test batch inscription %d", i)),
+		})
+	}
+
+	tests := []struct {
+		testCase     string
+		mode         mint.BatchMode
+		destinations [][]byte
+		outputs      []int
+		offsets      []int64
+	}{
+		{
+			testCase:     "test same sat batch",
+			mode:         mint.BatchModeSameSat,
+			destinations: [][]byte{testDestination(0x01)},
+			outputs:      []int{0, 0, 0},
+			offsets:      []int64{0, 0, 0},
+		},
+		{
+			testCase:     "test separate outputs batch",
+			mode:         mint.BatchModeSeparateOutputs,
+			destinations: [][]byte{testDestination(0x01), testDestination(0x02), testDestination(0x03)},
+			outputs:      []int{0, 1, 2},
+			offsets:      []int64{0, 0, 0},
+		},
+		{
+			testCase:     "test separate outputs batch with shared destination",
+			mode:         mint.BatchModeSeparateOutputs,
+			destinations: [][]byte{testDestination(0x01)},
+			outputs:      []int{0, 1, 2},
+			offsets:      []int64{0, 0, 0},
+		},
+		{
+			testCase:     "test shared output batch",
+			mode:         mint.BatchModeSharedOutput,
+			destinations: [][]byte{testDestination(0x01)},
+			outputs:      []int{0, 0, 0},
+			offsets:      []int64{0, mint.DefaultPostage, 2 * mint.DefaultPostage},
+		},
+	}
+
+	for _, test := range tests {
+		batch, err := mint.NewBatch(privateKey.PubKey(), &chaincfg.RegressionNetParams, test.mode,
+			mint.DefaultPostage, test.destinations, inscriptions...)
+		if err != nil {
+			t.Errorf("%s: test failed, %v", test.testCase, err)
+			continue
+		}
+		commitAmount := batch.CommitAmount(5)
+		commitOutPoint, prevOuts := testCommitTx(batch.Commit, commitAmount)
+		revealTx, err := batch.NewRevealTx(commitOutPoint, commitAmount, 5)
+		if err != nil {
+			t.Errorf("%s: test failed, %v", test.testCase, err)
+			continue
+		}
+		if err := batch.Commit.SignRevealTx(revealTx, commitOutPoint, privateKey, prevOuts); err != nil {
+			t.Errorf("%s: test failed, %v", test.testCase, err)
+			continue
+		}
+		testVerifyInput(t, revealTx, 0, prevOuts)
+
+		parsed := parser.ParseInscriptionsFromTransaction(revealTx)
+		if len(parsed) != len(inscriptions) {
+			t.Errorf("%s: test failed, found %d inscriptions", test.testCase, len(parsed))
+			continue
+		}
+		passed := true
+		for i, inscription := range parsed {
+			output, offset := testPointerOutput(revealTx.TxOut, inscription.Inscription.Pointer)
+			if output != test.outputs[i] || offset != test.offsets[i] ||
+				!bytes.Equal(inscription.Inscription.ContentBody, inscriptions[i].ContentBody) ||
+				!bytes.Equal(revealTx.TxOut[output].PkScript, test.destinations[i%len(test.destinations)]) {
+				t.Errorf("%s: test failed, inscription %d lands on output %d offset %d", test.testCase, i, output,
+					offset)
+				passed = false
+			}
+		}
+		if passed {
+			t.Logf("%s: test passed", test.testCase)
+		}
+	}
+
+	// The requested inscriptions are not modified
+	for _, inscription := range inscriptions {
+		if inscription.Pointer != nil {
+			t.Errorf("test batch reveal: requested inscription was modified")
+		}
+	}
+}
+
+func TestMintInvalidBatch(t *testing.T) {
+	t.Parallel()
+
+	key := testPrivateKey(0x04).PubKey()
+	inscription := &parser.InscriptionContent{ContentBody: []byte("test invalid batch")}
+	destinations := [][]byte{testDestination(0x01), testDestination(0x02)}
+
+	if _, err := mint.NewBatch(key, &chaincfg.MainNetParams, mint.BatchModeSameSat, mint.DefaultPostage,
+		destinations[:1]); !errors.Is(err, mint.ErrInvalidBatch) {
+		t.Errorf("test batch without inscriptions: test failed")
+	}
+	if _, err := mint.NewBatch(key, &chaincfg.MainNetParams, mint.BatchModeSharedOutput, mint.DefaultPostage,
+		destinations, inscription, inscription); !errors.Is(err, mint.ErrInvalidBatch) {
+		t.Errorf("test shared output batch with several destinations: test failed")
+	}
+	if _, err := mint.NewBatch(key, &chaincfg.MainNetParams, mint.BatchModeSeparateOutputs, mint.DefaultPostage,
+		destinations, inscription, inscription, inscription); !errors.Is(err, mint.ErrInvalidBatch) {
+		t.Errorf("test separate outputs batch with mismatched destinations: test failed")
+	}
+}
@@ -0,0 +1,114 @@
+package parser
+
+import (
+	"bytes"
+	"runtime"
+	"testing"
+
+	"github.com/balletcrypto/bitcoin-inscription-parser/parser"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// testInscriptionFreeTransactions returns the usual witness shapes found on chain: a segwit v0 spend, a taproot
+// key-path spend and a taproot script-path spend without envelope.
+func testInscriptionFreeTransactions() []*wire.MsgTx {
+	pubKey := append([]byte{0x02}, bytes.Repeat([]byte{0x11}, 32)...)
+	script, _ := txscript.NewScriptBuilder().
+		AddData(pubKey[1:]).
+		AddOp(txscript.OP_CHECKSIG).Script()
+
+	witnesses := []wire.TxWitness{
+		{make([]byte, 71), pubKey},
+		{make([]byte, 64)},
+		{make([]byte, 64), script, testControlBlock(0xc0, 1)},
+	}
+	var msgTxs []*wire.MsgTx
+	for _, witness := range witnesses {
+		msgTx := wire.NewMsgTx(wire.TxVersion)
+		msgTx.AddTxIn(wire.NewTxIn(&wire.OutPoint{}, nil, witness))
+		msgTx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: 1}, nil, witness))
+		msgTx.AddTxOut(wire.NewTxOut(1000, make([]byte, 34)))
+		msgTxs = append(msgTxs, msgTx)
+	}
+	return msgTxs
+}
+
+// testBenchmarkBlock returns a block of size transactions, of which inscribed hold the given number of envelopes.
+func testBenchmarkBlock(size, inscribed, envelopes int) *wire.MsgBlock {
+	builder := txscript.NewScriptBuilder()
+	for i := 0; i < envelopes; i++ {
+		testEnvelope(builder)
+	}
+	script, _ := builder.Script()
+
+	msgTxs := testInscriptionFreeTransactions()
+	msgBlock := wire.NewMsgBlock(&wire.BlockHeader{})
+	for i := 0; i < size; i++ {
+		msgTx := msgTxs[i%len(msgTxs)]
+		if i < inscribed {
+			msgTx = testTransaction(script)
+		}
+		_ = msgBlock.AddTransaction(msgTx)
+	}
+	return msgBlock
+}
+
+func TestParseInscriptionFreeTransactionAllocations(t *testing.T) {
+	for _, msgTx := range testInscriptionFreeTransactions() {
+		allocs := testing.AllocsPerRun(100, func() {
+			parser.ParseInscriptionsFromTransaction(msgTx)
+		})
+		if allocs != 0 {
+			t.Errorf("test inscription free transaction allocations failed, got %v allocations", allocs)
+		}
+	}
+}
+
+func BenchmarkParseInscriptionsFromBlock(b *testing.B) {
+	benchmarks := []struct {
+		name      string
+		inscribed int
+		envelopes int
+	}{
+		{name: "inscription free", inscribed: 0, envelopes: 0},
+		{name: "single inscription", inscribed: 1, envelopes: 1},
+		{name: "multiple inscriptions", inscribed: 500, envelopes: 3},
+	}
+
+	for _, benchmark := range benchmarks {
+		msgBlock := testBenchmarkBlock(2000, benchmark.inscribed, benchmark.envelopes)
+		b.Run(benchmark.name, func(b *testing.B) {
+			var before, after runtime.MemStats
+			b.ReportAllocs()
+			runtime.ReadMemStats(&before)
+			b.ResetTimer()
+			for i := 0; i < b.N; i++ {
+				parser.ParseInscriptionsFromBlock(msgBlock, 0)
+			}
+			b.StopTimer()
+			runtime.ReadMemStats(&after)
+			b.ReportMetric(float64(after.Mallocs-before.Mallocs)/float64(b.N*len(msgBlock.Transactions)), "allocs/tx")
+		})
+	}
+}
+
+func BenchmarkParseInscriptions(b *testing.B) {
+	script, _ := testEnvelope(txscript.NewScriptBuilder()).Script()
+	scriptWithoutEnvelope, _ := txscript.NewScriptBuilder().
+		AddData(bytes.Repeat([]byte{0x11}, 32)).
+		AddOp(txscript.OP_CHECKSIG).Script()
+
+	b.Run("inscription free", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			parser.ParseInscriptions(scriptWithoutEnvelope)
+		}
+	})
+	b.Run("single inscription", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			parser.ParseInscriptions(script)
+		}
+	})
+}
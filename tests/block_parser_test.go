@@ -0,0 +1,74 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/balletcrypto/bitcoin-inscription-parser/parser"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+func testBlock() *wire.MsgBlock {
+	script, _ := testEnvelope(txscript.NewScriptBuilder()).Script()
+	scriptWithTwoEnvelopes, _ := testEnvelope(testEnvelope(txscript.NewScriptBuilder())).Script()
+
+	coinbase := wire.NewMsgTx(wire.TxVersion)
+	coinbase.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: wire.MaxPrevOutIndex}, []byte{0x01, 0x02}, nil))
+	coinbase.AddTxOut(wire.NewTxOut(625000000, []byte{txscript.OP_TRUE}))
+
+	msgBlock := wire.NewMsgBlock(&wire.BlockHeader{})
+	_ = msgBlock.AddTransaction(coinbase)
+	for i := 0; i < 20; i++ {
+		var msgTx *wire.MsgTx
+		switch i % 3 {
+		case 0:
+			msgTx = testTransaction(script)
+		case 1:
+			msgTx = testTransaction(script, scriptWithTwoEnvelopes)
+		default:
+			msgTx = wire.NewMsgTx(wire.TxVersion)
+			msgTx.AddTxIn(wire.NewTxIn(&wire.OutPoint{}, nil, wire.TxWitness{make([]byte, 64)}))
+		}
+		// Make every transaction unique
+		msgTx.LockTime = uint32(i)
+		_ = msgBlock.AddTransaction(msgTx)
+	}
+	return msgBlock
+}
+
+func TestParseInscriptionsFromBlock(t *testing.T) {
+	t.Parallel()
+
+	msgBlock := testBlock()
+	blockInscriptions := parser.ParseInscriptionsFromBlock(msgBlock, 800000)
+
+	// Transactions 1, 2, 4, 5, ... have inscriptions, every third one does not
+	if len(blockInscriptions.Transactions) != 14 || len(blockInscriptions.ByTxID) != 14 {
+		t.Fatalf("test parse inscriptions from block: unexpected transactions %d",
+			len(blockInscriptions.Transactions))
+	}
+	for i, transaction := range blockInscriptions.Transactions {
+		msgTx := msgBlock.Transactions[transaction.TxIndex]
+		if transaction.TxID != msgTx.TxHash() || transaction.BlockHeight != 800000 ||
+			blockInscriptions.ByTxID[transaction.TxID] != transaction ||
+			(i > 0 && transaction.TxIndex <= blockInscriptions.Transactions[i-1].TxIndex) {
+			t.Errorf("test parse inscriptions from block: unexpected transaction %d", i)
+		}
+		for j, inscription := range transaction.Inscriptions {
+			if inscription.InscriptionID.TxID != transaction.TxID || inscription.InscriptionID.Index != uint32(j) {
+				t.Errorf("test parse inscriptions from block: unexpected inscription ID %s",
+					inscription.InscriptionID)
+			}
+		}
+	}
+
+	for _, workers := range []int{0, 2, 8, 64} {
+		parallel := parser.ParseInscriptionsFromBlockWithWorkers(msgBlock, 800000, workers)
+		if reflect.DeepEqual(parallel, blockInscriptions) {
+			t.Logf("test parse inscriptions from block with %d workers: test passed", workers)
+		} else {
+			t.Errorf("test parse inscriptions from block with %d workers: test failed", workers)
+		}
+	}
+}
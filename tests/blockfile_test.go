@@ -0,0 +1,153 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/binary"
+	"errors"
+	"io"
+	"os"
+	"path/filepath"
+	"testing"
+
+	"github.com/balletcrypto/bitcoin-inscription-parser/blockfile"
+	"github.com/balletcrypto/bitcoin-inscription-parser/parser"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+func testChildBlock(prev *wire.MsgBlock, nonce uint32, msgTxs ...*wire.MsgTx) *wire.MsgBlock {
+	msgBlock := wire.NewMsgBlock(&wire.BlockHeader{
+		PrevBlock: prev.BlockHash(),
+		Bits:      chaincfg.RegressionNetParams.PowLimitBits,
+		Nonce:     nonce,
+	})
+	coinbase := wire.NewMsgTx(wire.TxVersion)
+	coinbase.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: wire.MaxPrevOutIndex}, []byte{byte(nonce), 0x01}, nil))
+	coinbase.AddTxOut(wire.NewTxOut(5000000000, []byte{txscript.OP_TRUE}))
+	_ = msgBlock.AddTransaction(coinbase)
+	for _, msgTx := range msgTxs {
+		_ = msgBlock.AddTransaction(msgTx)
+	}
+	return msgBlock
+}
+
+// testBlockFile serializes blocks the way Bitcoin Core stores them, obfuscated with key, followed by the zero
+// padding of the preallocated file, which Bitcoin Core does not obfuscate.
+func testBlockFile(key []byte, blocks ...*wire.MsgBlock) []byte {
+	var buf bytes.Buffer
+	for _, msgBlock := range blocks {
+		var prefix [8]byte
+		binary.LittleEndian.PutUint32(prefix[:4], uint32(chaincfg.RegressionNetParams.Net))
+		binary.LittleEndian.PutUint32(prefix[4:], uint32(msgBlock.SerializeSize()))
+		buf.Write(prefix[:])
+		_ = msgBlock.Serialize(&buf)
+	}
+	data := buf.Bytes()
+	for i := range data {
+		if len(key) > 0 {
+			data[i] ^= key[i%len(key)]
+		}
+	}
+	return append(data, make([]byte, 100)...)
+}
+
+func TestBlockFileReader(t *testing.T) {
+	t.Parallel()
+
+	genesis := chaincfg.RegressionNetParams.GenesisBlock
+	block1 := testChildBlock(genesis, 1)
+	key := []byte{0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef}
+
+	for _, key := range [][]byte{nil, key} {
+		reader := blockfile.NewReader(bytes.NewReader(testBlockFile(key, genesis, block1)),
+			chaincfg.RegressionNetParams.Net, key)
+		var hashes []chainhash.Hash
+		for {
+			msgBlock, _, err := reader.Next()
+			if err == io.EOF {
+				break
+			} else if err != nil {
+				t.Fatalf("test block file reader failed, %v", err)
+			}
+			hashes = append(hashes, msgBlock.BlockHash())
+		}
+		if len(hashes) != 2 || hashes[0] != genesis.BlockHash() || hashes[1] != block1.BlockHash() {
+			t.Errorf("test block file reader with key %x failed, got %v", key, hashes)
+		}
+	}
+
+	reader := blockfile.NewReader(bytes.NewReader(testBlockFile(nil, genesis)), chaincfg.MainNetParams.Net, nil)
+	if _, _, err := reader.Next(); !errors.Is(err, blockfile.ErrInvalidMagic) {
+		t.Errorf("test block file reader with wrong network failed, got %v", err)
+	}
+}
+
+func TestBlockFileDir(t *testing.T) {
+	t.Parallel()
+
+	script, _ := testEnvelope(txscript.NewScriptBuilder()).Script()
+	genesis := chaincfg.RegressionNetParams.GenesisBlock
+	block1 := testChildBlock(genesis, 1)
+	block2 := testChildBlock(block1, 2, testTransaction(script))
+	block3 := testChildBlock(block2, 3)
+	fork2 := testChildBlock(block1, 4)
+	orphan := testChildBlock(testChildBlock(genesis, 5), 6)
+	key := []byte{0x01, 0x23, 0x45, 0x67, 0x89, 0xab, 0xcd, 0xef}
+
+	blocksDir := t.TempDir()
+	files := map[string][]byte{
+		"blk00000.dat":       testBlockFile(key, genesis, block1, fork2),
+		"blk00001.dat":       testBlockFile(key, orphan, block3, block2, block1),
+		blockfile.XORKeyFile: key,
+	}
+	for name, data := range files {
+		if err := os.WriteFile(filepath.Join(blocksDir, name), data, 0o600); err != nil {
+			t.Fatalf("test block file dir failed, %v", err)
+		}
+	}
+
+	dir, err := blockfile.OpenDir(blocksDir, &chaincfg.RegressionNetParams)
+	if err != nil {
+		t.Fatalf("test block file dir failed, %v", err)
+	}
+	chain, err := dir.Index()
+	if err != nil {
+		t.Fatalf("test block file dir failed, %v", err)
+	}
+	expected := []*wire.MsgBlock{genesis, block1, block2, block3}
+	if len(chain) != len(expected) {
+		t.Fatalf("test block file dir failed, expected %d blocks, got %d", len(expected), len(chain))
+	}
+	for height, location := range chain {
+		if location.Hash != expected[height].BlockHash() || location.Height != int32(height) {
+			t.Errorf("test block file dir failed, unexpected block %s at height %d", location.Hash, height)
+		}
+	}
+
+	msgBlock, err := dir.ReadBlock(chain[2])
+	if err != nil || msgBlock.BlockHash() != block2.BlockHash() {
+		t.Errorf("test block file dir failed, could not read block 2: %v", err)
+	}
+
+	var inscriptions []*parser.BlockInscriptions
+	err = dir.ParseInscriptions(chain, func(blockInscriptions *parser.BlockInscriptions) error {
+		inscriptions = append(inscriptions, blockInscriptions)
+		return nil
+	})
+	if err != nil {
+		t.Fatalf("test block file dir failed, %v", err)
+	}
+	if len(inscriptions) != 4 || len(inscriptions[2].Transactions) != 1 || inscriptions[2].BlockHeight != 2 {
+		t.Errorf("test block file dir failed, inscriptions not found in block 2")
+	}
+
+	if err := os.Remove(filepath.Join(blocksDir, "blk00000.dat")); err != nil {
+		t.Fatalf("test block file dir failed, %v", err)
+	}
+	dir, _ = blockfile.OpenDir(blocksDir, &chaincfg.RegressionNetParams)
+	if _, err := dir.Index(); !errors.Is(err, blockfile.ErrGenesisNotFound) {
+		t.Errorf("test block file dir without genesis failed, got %v", err)
+	}
+}
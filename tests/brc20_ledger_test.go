@@ -0,0 +1,168 @@
+package parser
+
+import (
+	"encoding/json"
+	"errors"
+	"os"
+	"path/filepath"
+	"reflect"
+	"testing"
+
+	"github.com/balletcrypto/bitcoin-inscription-parser/brc20"
+	"github.com/balletcrypto/bitcoin-inscription-parser/parser"
+)
+
+// testBRC20Fixture is a history of events with the expected outcome of each event and the expected final state.
+type testBRC20Fixture struct {
+	Events []struct {
+		Inscribe *struct {
+			ID          parser.InscriptionID   `json:"id"`
+			Number      int32                  `json:"number"`
+			Height      int32                  `json:"height"`
+			ContentType string                 `json:"content_type"`
+			Body        string                 `json:"body"`
+			Owner       string                 `json:"owner"`
+			Parents     []parser.InscriptionID `json:"parents"`
+			SentToFee   bool                   `json:"sent_to_fee"`
+		} `json:"inscribe"`
+		Transfer *struct {
+			ID        parser.InscriptionID `json:"id"`
+			Height    int32                `json:"height"`
+			To        string               `json:"to"`
+			SentToFee bool                 `json:"sent_to_fee"`
+		} `json:"transfer"`
+		// Error is the name of the expected brc20 error, if any
+		Error string `json:"error"`
+	} `json:"events"`
+	Tokens   map[string]testBRC20Token              `json:"tokens"`
+	Balances map[string]map[string]testBRC20Balance `json:"balances"`
+}
+
+type testBRC20Token struct {
+	Max      string               `json:"max"`
+	Limit    string               `json:"limit"`
+	Decimals int                  `json:"decimals"`
+	SelfMint bool                 `json:"self_mint"`
+	Minted   string               `json:"minted"`
+	DeployID parser.InscriptionID `json:"deploy_id"`
+}
+
+type testBRC20Balance struct {
+	Available    string `json:"available"`
+	Transferable string `json:"transferable"`
+}
+
+var testBRC20Errors = map[string]error{
+	"ErrContentType":          brc20.ErrContentType,
+	"ErrInvalidJSON":          brc20.ErrInvalidJSON,
+	"ErrPrecision":            brc20.ErrPrecision,
+	"ErrOutOfOrder":           brc20.ErrOutOfOrder,
+	"ErrCursed":               brc20.ErrCursed,
+	"ErrNoOwner":              brc20.ErrNoOwner,
+	"ErrSelfMintNotActive":    brc20.ErrSelfMintNotActive,
+	"ErrAlreadyDeployed":      brc20.ErrAlreadyDeployed,
+	"ErrNotDeployed":          brc20.ErrNotDeployed,
+	"ErrMintLimit":            brc20.ErrMintLimit,
+	"ErrMintedOut":            brc20.ErrMintedOut,
+	"ErrSelfMintParent":       brc20.ErrSelfMintParent,
+	"ErrInsufficientBalance":  brc20.ErrInsufficientBalance,
+	"ErrTransferAlreadyMoved": brc20.ErrTransferAlreadyMoved,
+}
+
+func TestBRC20Ledger(t *testing.T) {
+	t.Parallel()
+
+	// Histories copied from mainnet with their published end balances go in mainnet, synthetic histories of edge
+	// cases in edge_cases. Both are required, the edge cases do not replace mainnet histories.
+	mainnetPaths, _ := filepath.Glob(filepath.Join("testdata", "brc20", "mainnet", "*.json"))
+	edgeCasePaths, _ := filepath.Glob(filepath.Join("testdata", "brc20", "edge_cases", "*.json"))
+	if len(mainnetPaths) == 0 {
+		t.Errorf("test brc20 ledger failed, no mainnet histories in testdata/brc20/mainnet")
+	}
+	if len(edgeCasePaths) == 0 {
+		t.Errorf("test brc20 ledger failed, no edge cases in testdata/brc20/edge_cases")
+	}
+	paths := append(mainnetPaths, edgeCasePaths...)
+	for _, path := range paths {
+		data, err := os.ReadFile(path)
+		if err != nil {
+			t.Fatalf("test brc20 ledger failed, %v", err)
+		}
+		var fixture testBRC20Fixture
+		if err := json.Unmarshal(data, &fixture); err != nil {
+			t.Fatalf("test brc20 ledger failed, %s: %v", path, err)
+		}
+
+		ledger := brc20.NewLedger()
+		for i, event := range fixture.Events {
+			var err error
+			switch {
+			case event.Inscribe != nil:
+				inscribe := event.Inscribe
+				_, err = ledger.Inscribe(&brc20.InscribeEvent{
+					Inscription: &parser.TransactionInscription{
+						Inscription: &parser.InscriptionContent{
+							ContentType: []byte(inscribe.ContentType),
+							ContentBody: []byte(inscribe.Body),
+							Parents:     inscribe.Parents,
+						},
+						InscriptionID: inscribe.ID,
+					},
+					Number:    inscribe.Number,
+					Height:    inscribe.Height,
+					Owner:     inscribe.Owner,
+					SentToFee: inscribe.SentToFee,
+				})
+			case event.Transfer != nil:
+				transfer := event.Transfer
+				_, err = ledger.Transfer(&brc20.TransferEvent{
+					ID:        transfer.ID,
+					Height:    transfer.Height,
+					To:        transfer.To,
+					SentToFee: transfer.SentToFee,
+				})
+			}
+			if expected := testBRC20Errors[event.Error]; event.Error != "" && expected == nil {
+				t.Fatalf("test brc20 ledger failed, %s event %d: unknown error %s", path, i, event.Error)
+			} else if !errors.Is(err, expected) || (expected == nil && err != nil) {
+				t.Errorf("test brc20 ledger failed, %s event %d: expected %v, got %v", path, i, expected, err)
+			}
+		}
+
+		tokens := map[string]testBRC20Token{}
+		for _, token := range ledger.Tokens() {
+			tokens[token.Ticker] = testBRC20Token{
+				Max:      brc20.FormatAmount(token.Max),
+				Limit:    brc20.FormatAmount(token.Limit),
+				Decimals: token.Decimals,
+				SelfMint: token.SelfMint,
+				Minted:   brc20.FormatAmount(token.Minted),
+				DeployID: token.DeployID,
+			}
+		}
+		if !reflect.DeepEqual(tokens, fixture.Tokens) {
+			t.Errorf("test brc20 ledger failed, %s: expected tokens %+v, got %+v", path, fixture.Tokens, tokens)
+		}
+
+		// Zero balances are left out
+		balances := map[string]map[string]testBRC20Balance{}
+		for _, owner := range ledger.Owners() {
+			for tick := range tokens {
+				balance := ledger.Balance(owner, tick)
+				if balance.Available.Sign() == 0 && balance.Transferable.Sign() == 0 {
+					continue
+				}
+				if balances[owner] == nil {
+					balances[owner] = map[string]testBRC20Balance{}
+				}
+				balances[owner][tick] = testBRC20Balance{
+					Available:    brc20.FormatAmount(balance.Available),
+					Transferable: brc20.FormatAmount(balance.Transferable),
+				}
+			}
+		}
+		if !reflect.DeepEqual(balances, fixture.Balances) {
+			t.Errorf("test brc20 ledger failed, %s: expected balances %+v, got %+v", path, fixture.Balances, balances)
+		}
+	}
+}
@@ -0,0 +1,181 @@
+package parser
+
+import (
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/balletcrypto/bitcoin-inscription-parser/brc20"
+	"github.com/balletcrypto/bitcoin-inscription-parser/parser"
+)
+
+func TestBRC20Parse(t *testing.T) {
+	t.Parallel()
+
+	amount := func(s string) *big.Int {
+		value, err := brc20.ParseAmount(s, brc20.MaxDecimals)
+		if err != nil {
+			t.Fatalf("test brc20 parse failed, %v", err)
+		}
+		return value
+	}
+	testCases := []struct {
+		testCase string
+		body     string
+		expected brc20.Operation
+		err      error
+	}{
+		{
+			testCase: "deploy",
+			body:     `{"p":"brc-20","op":"deploy","tick":"ORDI","max":"21000000","lim":"1000"}`,
+			expected: &brc20.Deploy{Ticker: "ordi", Max: amount("21000000"), Limit: amount("1000"), Decimals: 18},
+		},
+		{
+			testCase: "deploy with decimals and no limit",
+			body:     `{"p":"brc-20","op":"deploy","tick":"sats","max":"2100.5","dec":"2"}`,
+			expected: &brc20.Deploy{Ticker: "sats", Max: amount("2100.5"), Limit: amount("2100.5"), Decimals: 2},
+		},
+		{
+			testCase: "deploy with zero decimals",
+			body:     `{"p":"brc-20","op":"deploy","tick":"pizza","max":"0","self_mint":"true","dec":"0"}`,
+			expected: &brc20.Deploy{Ticker: "pizza", Max: brc20.MaxAmount, Limit: brc20.MaxAmount, SelfMint: true},
+		},
+		{
+			testCase: "5 byte deploy without self mint",
+			body:     `{"p":"brc-20","op":"deploy","tick":"pizza","max":"100"}`,
+			err:      brc20.ErrSelfMintMissing,
+		},
+		{
+			testCase: "mint",
+			body:     `{"p":"brc-20","op":"mint","tick":"OrDi","amt":"1000.000000000000000001"}`,
+			expected: &brc20.Mint{Ticker: "ordi", Amount: amount("1000.000000000000000001"), AmountDecimals: 18},
+		},
+		{
+			testCase: "transfer",
+			body:     "{\n  \"p\": \"brc-20\",\n  \"op\": \"transfer\",\n  \"tick\": \"ordi\",\n  \"amt\": \"0.5\"\n}",
+			expected: &brc20.Transfer{Ticker: "ordi", Amount: amount("0.5"), AmountDecimals: 1},
+		},
+		{
+			testCase: "multi-byte tick",
+			body:     `{"p":"brc-20","op":"mint","tick":"𝛑π","amt":"1"}`,
+			err:      brc20.ErrTick,
+		},
+		{
+			testCase: "4 byte multi-byte tick",
+			body:     `{"p":"brc-20","op":"mint","tick":"😀","amt":"1"}`,
+			expected: &brc20.Mint{Ticker: "😀", Amount: amount("1")},
+		},
+		{testCase: "uppercase protocol", body: `{"p":"BRC-20","op":"mint","tick":"ordi","amt":"1"}`, err: brc20.ErrProtocol},
+		{testCase: "uppercase op", body: `{"p":"brc-20","op":"Mint","tick":"ordi","amt":"1"}`, err: brc20.ErrOp},
+		{testCase: "3 byte tick", body: `{"p":"brc-20","op":"mint","tick":"ord","amt":"1"}`, err: brc20.ErrTick},
+		{testCase: "numeric amount", body: `{"p":"brc-20","op":"mint","tick":"ordi","amt":1}`, err: brc20.ErrNotString},
+		{testCase: "null amount", body: `{"p":"brc-20","op":"mint","tick":"ordi","amt":null}`, err: brc20.ErrNotString},
+		{testCase: "missing amount", body: `{"p":"brc-20","op":"mint","tick":"ordi"}`, err: brc20.ErrMissingField},
+		{testCase: "missing max", body: `{"p":"brc-20","op":"deploy","tick":"ordi"}`, err: brc20.ErrMissingField},
+		{testCase: "zero amount", body: `{"p":"brc-20","op":"mint","tick":"ordi","amt":"0.0"}`, err: brc20.ErrAmountRange},
+		{testCase: "negative", body: `{"p":"brc-20","op":"mint","tick":"ordi","amt":"-1"}`, err: brc20.ErrInvalidNumber},
+		{testCase: "leading dot", body: `{"p":"brc-20","op":"mint","tick":"ordi","amt":".5"}`, err: brc20.ErrInvalidNumber},
+		{testCase: "trailing dot", body: `{"p":"brc-20","op":"mint","tick":"ordi","amt":"5."}`, err: brc20.ErrInvalidNumber},
+		{testCase: "two dots", body: `{"p":"brc-20","op":"mint","tick":"ordi","amt":"1.2.3"}`, err: brc20.ErrInvalidNumber},
+		{testCase: "exponent", body: `{"p":"brc-20","op":"mint","tick":"ordi","amt":"1e3"}`, err: brc20.ErrInvalidNumber},
+		{testCase: "space", body: `{"p":"brc-20","op":"mint","tick":"ordi","amt":" 1"}`, err: brc20.ErrInvalidNumber},
+		{
+			testCase: "too many decimals",
+			body:     `{"p":"brc-20","op":"mint","tick":"ordi","amt":"0.0000000000000000001"}`,
+			err:      brc20.ErrPrecision,
+		},
+		{
+			testCase: "max beyond deploy decimals",
+			body:     `{"p":"brc-20","op":"deploy","tick":"ordi","max":"1.001","dec":"2"}`,
+			err:      brc20.ErrPrecision,
+		},
+		{
+			testCase: "decimals above 18",
+			body:     `{"p":"brc-20","op":"deploy","tick":"ordi","max":"1","dec":"19"}`,
+			err:      brc20.ErrDecimals,
+		},
+		{
+			testCase: "decimals with a dot",
+			body:     `{"p":"brc-20","op":"deploy","tick":"ordi","max":"1","dec":"2.0"}`,
+			err:      brc20.ErrInvalidNumber,
+		},
+		{
+			testCase: "max above 2^64-1",
+			body:     `{"p":"brc-20","op":"deploy","tick":"ordi","max":"18446744073709551616"}`,
+			err:      brc20.ErrAmountRange,
+		},
+		{
+			testCase: "max of 2^64-1",
+			body:     `{"p":"brc-20","op":"deploy","tick":"ordi","max":"18446744073709551615","lim":"1"}`,
+			expected: &brc20.Deploy{Ticker: "ordi", Max: brc20.MaxAmount, Limit: amount("1"), Decimals: 18},
+		},
+		{
+			testCase: "zero limit",
+			body:     `{"p":"brc-20","op":"deploy","tick":"ordi","max":"1","lim":"0"}`,
+			err:      brc20.ErrAmountRange,
+		},
+		{testCase: "not an object", body: `["brc-20"]`, err: brc20.ErrInvalidJSON},
+		{testCase: "invalid JSON", body: `{"p":"brc-20",}`, err: brc20.ErrInvalidJSON},
+	}
+
+	for _, testCase := range testCases {
+		operation, err := brc20.Parse(&parser.InscriptionContent{
+			ContentType: []byte("text/plain;charset=utf-8"),
+			ContentBody: []byte(testCase.body),
+		})
+		if testCase.err != nil {
+			if !errors.Is(err, testCase.err) {
+				t.Errorf("test %s failed, expected %v, got %v", testCase.testCase, testCase.err, err)
+			}
+			continue
+		}
+		if err != nil {
+			t.Errorf("test %s failed, %v", testCase.testCase, err)
+			continue
+		}
+		if !testBRC20OperationEqual(operation, testCase.expected) {
+			t.Errorf("test %s failed, expected %+v, got %+v", testCase.testCase, testCase.expected, operation)
+		}
+	}
+}
+
+func testBRC20OperationEqual(a, b brc20.Operation) bool {
+	switch a := a.(type) {
+	case *brc20.Deploy:
+		b, ok := b.(*brc20.Deploy)
+		return ok && a.Ticker == b.Ticker && a.Max.Cmp(b.Max) == 0 && a.Limit.Cmp(b.Limit) == 0 &&
+			a.Decimals == b.Decimals && a.SelfMint == b.SelfMint
+	case *brc20.Mint:
+		b, ok := b.(*brc20.Mint)
+		return ok && a.Ticker == b.Ticker && a.Amount.Cmp(b.Amount) == 0 && a.AmountDecimals == b.AmountDecimals
+	case *brc20.Transfer:
+		b, ok := b.(*brc20.Transfer)
+		return ok && a.Ticker == b.Ticker && a.Amount.Cmp(b.Amount) == 0 && a.AmountDecimals == b.AmountDecimals
+	}
+	return false
+}
+
+func TestBRC20ContentType(t *testing.T) {
+	t.Parallel()
+
+	body := []byte(`{"p":"brc-20","op":"mint","tick":"ordi","amt":"1000"}`)
+	for contentType, valid := range map[string]bool{
+		"text/plain":                true,
+		"text/plain;charset=utf-8":  true,
+		"application/json":          true,
+		"application/json; foo=bar": true,
+		"text/html":                 false,
+		"TEXT/PLAIN":                false,
+		"":                          false,
+	} {
+		_, err := brc20.Parse(&parser.InscriptionContent{ContentType: []byte(contentType), ContentBody: body})
+		if valid != (err == nil) || (!valid && !errors.Is(err, brc20.ErrContentType)) {
+			t.Errorf("test brc20 content type %q failed, got %v", contentType, err)
+		}
+	}
+
+	amount := brc20.FormatAmount(new(big.Int).Add(brc20.MaxAmount, big.NewInt(5)))
+	if amount != "18446744073709551615.000000000000000005" {
+		t.Errorf("test format amount failed, got %s", amount)
+	}
+}
@@ -0,0 +1,165 @@
+package parser
+
+import (
+	"encoding/hex"
+	"errors"
+	"math"
+	"math/big"
+	"reflect"
+	"strings"
+	"testing"
+
+	"github.com/balletcrypto/bitcoin-inscription-parser/cbor"
+	"github.com/balletcrypto/bitcoin-inscription-parser/parser"
+	"github.com/btcsuite/btcd/txscript"
+)
+
+func TestCBORDecode(t *testing.T) {
+	t.Parallel()
+
+	bigNegative, _ := new(big.Int).SetString("-18446744073709551616", 10)
+	bignum, _ := new(big.Int).SetString("18446744073709551616", 10)
+
+	// Test vectors from RFC 8949 appendix A
+	tests := []struct {
+		encoded  string
+		expected interface{}
+	}{
+		{"00", uint64(0)},
+		{"17", uint64(23)},
+		{"1818", uint64(24)},
+		{"1903e8", uint64(1000)},
+		{"1bffffffffffffffff", uint64(math.MaxUint64)},
+		{"20", int64(-1)},
+		{"3903e7", int64(-1000)},
+		{"3bffffffffffffffff", bigNegative},
+		{"c249010000000000000000", bignum},
+		{"f90000", 0.0},
+		{"f93c00", 1.0},
+		{"f97bff", 65504.0},
+		{"f90001", 5.960464477539063e-8},
+		{"fa47c35000", 100000.0},
+		{"fb3ff199999999999a", 1.1},
+		{"f4", false},
+		{"f5", true},
+		{"f6", nil},
+		{"f7", cbor.Undefined{}},
+		{"f0", cbor.Simple(16)},
+		{"f8ff", cbor.Simple(255)},
+		{"c074323031332d30332d32315432303a30343a30305a", cbor.Tag{Number: 0, Content: "2013-03-21T20:04:00Z"}},
+		{"4401020304", []byte{1, 2, 3, 4}},
+		{"6449455446", "IETF"},
+		{"62c3bc", "ü"},
+		{"83010203", []interface{}{uint64(1), uint64(2), uint64(3)}},
+		{"a201020304", cbor.Map{{Key: uint64(1), Value: uint64(2)}, {Key: uint64(3), Value: uint64(4)}}},
+		{"a26161016162820203", cbor.Map{
+			{Key: "a", Value: uint64(1)},
+			{Key: "b", Value: []interface{}{uint64(2), uint64(3)}},
+		}},
+		{"5f42010243030405ff", []byte{1, 2, 3, 4, 5}},
+		{"7f657374726561646d696e67ff", "streaming"},
+		{"9f018202039f0405ffff", []interface{}{
+			uint64(1), []interface{}{uint64(2), uint64(3)}, []interface{}{uint64(4), uint64(5)},
+		}},
+		{"bf61610161629f0203ffff", cbor.Map{
+			{Key: "a", Value: uint64(1)},
+			{Key: "b", Value: []interface{}{uint64(2), uint64(3)}},
+		}},
+	}
+
+	for _, test := range tests {
+		data, _ := hex.DecodeString(test.encoded)
+		value, err := cbor.Decode(data)
+		if err != nil {
+			t.Errorf("test decode %s: test failed, %v", test.encoded, err)
+			continue
+		}
+		if n, ok := test.expected.(*big.Int); ok {
+			if v, ok := value.(*big.Int); !ok || v.Cmp(n) != 0 {
+				t.Errorf("test decode %s: test failed, %v", test.encoded, value)
+			}
+			continue
+		}
+		if !reflect.DeepEqual(value, test.expected) {
+			t.Errorf("test decode %s: test failed, %#v", test.encoded, value)
+		}
+	}
+}
+
+func TestCBORDecodeErrors(t *testing.T) {
+	t.Parallel()
+
+	limits := cbor.DecodeOptions{MaxSize: 64, MaxDepth: 4, MaxItems: 8}
+
+	tests := []struct {
+		testCase string
+		encoded  string
+		expected error
+	}{
+		{"test truncated integer", "19", cbor.ErrUnexpectedEnd},
+		{"test truncated string", "6449", cbor.ErrUnexpectedEnd},
+		{"test huge declared array", "9bffffffffffffffff", cbor.ErrUnexpectedEnd},
+		{"test huge declared map", "bb7fffffffffffffff", cbor.ErrUnexpectedEnd},
+		{"test unterminated indefinite array", "9f01", cbor.ErrUnexpectedEnd},
+		{"test trailing data", "0000", cbor.ErrTrailingData},
+		{"test reserved additional information", "1c", cbor.ErrMalformed},
+		{"test lone break code", "ff", cbor.ErrMalformed},
+		{"test indefinite integer", "1f", cbor.ErrMalformed},
+		{"test mismatched string chunk", "5f6161ff", cbor.ErrMalformed},
+		{"test invalid UTF-8", "62c328", cbor.ErrInvalidUTF8},
+		{"test deep nesting", "818181818100", cbor.ErrTooDeep},
+		{"test deep tags", "c1c1c1c1c100", cbor.ErrTooDeep},
+		{"test too many items", "8900000000000000000000", cbor.ErrTooManyItems},
+		{"test too large", "5841" + strings.Repeat("00", 65), cbor.ErrTooLarge},
+	}
+
+	for _, test := range tests {
+		data, _ := hex.DecodeString(test.encoded)
+		if _, err := limits.Decode(data); errors.Is(err, test.expected) {
+			t.Logf("%s: test passed", test.testCase)
+		} else {
+			t.Errorf("%s: test failed, error: %v", test.testCase, err)
+		}
+	}
+}
+
+func TestInscriptionMetadata(t *testing.T) {
+	t.Parallel()
+
+	// {"name": "test", "attributes": [{"trait": h'0102', 1: -1.5}], "undefined": undefined}
+	metadata, _ := hex.DecodeString("a3646e616d6564746573746a617474726962757465738" +
+		"1a2657472616974420102" + "01f9be00" + "69756e646566696e6564f7")
+	script, _ := txscript.NewScriptBuilder().
+		AddOps([]byte{txscript.OP_FALSE, txscript.OP_IF}).
+		AddData([]byte("ord")).
+		AddOp(txscript.OP_DATA_1).
+		AddOp(txscript.OP_DATA_5).
+		AddData(metadata[:10]).
+		AddOp(txscript.OP_DATA_1).
+		AddOp(txscript.OP_DATA_5).
+		AddData(metadata[10:]).
+		AddOp(txscript.OP_ENDIF).Script()
+
+	inscriptions := parser.ParseInscriptions(script)
+	if len(inscriptions) != 1 {
+		t.Fatalf("test inscription metadata: expected 1 inscription, got %d", len(inscriptions))
+	}
+	value, err := inscriptions[0].Metadata()
+	if err != nil {
+		t.Fatalf("test inscription metadata: %v", err)
+	}
+	if name, ok := value.(cbor.Map).Get("name"); !ok || name != "test" {
+		t.Errorf("test inscription metadata: unexpected name %v", name)
+	}
+
+	encoded, err := inscriptions[0].MetadataJSON()
+	expected := `{"name":"test","attributes":[{"trait":"0102","1":-1.5}],"undefined":null}`
+	if err != nil || string(encoded) != expected {
+		t.Errorf("test inscription metadata JSON: test failed, %s, error: %v", encoded, err)
+	}
+
+	withoutMetadata := &parser.InscriptionContent{}
+	if value, err := withoutMetadata.Metadata(); value != nil || err != nil {
+		t.Errorf("test inscription without metadata: test failed")
+	}
+}
@@ -0,0 +1,199 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"flag"
+	"os"
+	"path/filepath"
+	"strings"
+	"testing"
+
+	"github.com/balletcrypto/bitcoin-inscription-parser/cli"
+	"github.com/balletcrypto/bitcoin-inscription-parser/parser"
+	"github.com/btcsuite/btcd/txscript"
+)
+
+func TestCLIReadInput(t *testing.T) {
+	t.Parallel()
+
+	data := []byte{0x01, 0x02, 0xff}
+	dir := t.TempDir()
+	rawFile := filepath.Join(dir, "raw")
+	hexFile := filepath.Join(dir, "hex")
+	_ = os.WriteFile(rawFile, data, 0o600)
+	_ = os.WriteFile(hexFile, []byte(hex.EncodeToString(data)+"\n"), 0o600)
+
+	testCases := []struct {
+		testCase string
+		args     []string
+		stdin    string
+		invalid  bool
+		err      error
+	}{
+		{testCase: "hex argument", args: []string{"0102ff"}},
+		{testCase: "raw file", args: []string{rawFile}},
+		{testCase: "hex file", args: []string{hexFile}},
+		{testCase: "raw stdin", stdin: string(data)},
+		{testCase: "hex stdin", stdin: " 0102ff\n"},
+		{testCase: "neither file nor hex", args: []string{"not hex"}, invalid: true},
+		{testCase: "two inputs", args: []string{"0102ff", "0102ff"}, invalid: true, err: cli.ErrUsage},
+	}
+	for _, tc := range testCases {
+		input, err := cli.ReadInput(tc.args, strings.NewReader(tc.stdin))
+		switch {
+		case !tc.invalid && (err != nil || !bytes.Equal(input, data)):
+			t.Errorf("test %s failed, input %x, error %v", tc.testCase, input, err)
+		case tc.invalid && (err == nil || (tc.err != nil && !errors.Is(err, tc.err))):
+			t.Errorf("test %s failed, error %v, expected %v", tc.testCase, err, tc.err)
+		default:
+			t.Logf("%s: test passed", tc.testCase)
+		}
+	}
+}
+
+func TestCLICommands(t *testing.T) {
+	t.Parallel()
+
+	script, _ := testEnvelope(txscript.NewScriptBuilder()).Script()
+	scriptWithTwoEnvelopes, _ := testEnvelope(testEnvelope(txscript.NewScriptBuilder())).Script()
+	msgTx := testTransaction(script, scriptWithTwoEnvelopes)
+	txHex := testHex(msgTx.Serialize)
+	blockHex := testHex(testBlock().Serialize)
+	firstID := parser.InscriptionID{TxID: msgTx.TxHash()}.String()
+
+	testCases := []struct {
+		testCase string
+		args     []string
+		stdin    string
+		err      error
+		// count is the number of inscriptions in the JSON output
+		count int
+		// contains are substrings of the output
+		contains []string
+	}{
+		{
+			testCase: "tx json",
+			args:     []string{"tx", txHex},
+			count:    3,
+			contains: []string{`"id": "` + firstID + `"`, `"curses": [`, `"content_type": "text/plain;charset=utf-8"`},
+		},
+		{
+			testCase: "tx table from stdin",
+			args:     []string{"tx", "-format", "table"},
+			stdin:    txHex,
+			contains: []string{"ID  ", "CURSES", firstID + "  0      0       text/plain;charset=utf-8  11      -"},
+		},
+		{
+			testCase: "script json",
+			args:     []string{"script", hex.EncodeToString(scriptWithTwoEnvelopes)},
+			count:    2,
+			contains: []string{`"txin_offset": 1`},
+		},
+		{
+			testCase: "script table",
+			args:     []string{"script", "-format", "table", hex.EncodeToString(script)},
+			contains: []string{"-   0      0       text/plain;charset=utf-8  11      -"},
+		},
+		{
+			testCase: "block json",
+			args:     []string{"block", "-height", "800000", blockHex},
+			count:    28,
+			contains: []string{`"block_height": 800000`},
+		},
+		{testCase: "help", args: []string{"help"}, contains: []string{"Commands:"}},
+		{testCase: "command help", args: []string{"tx", "-h"}, err: flag.ErrHelp},
+		{testCase: "no command", err: cli.ErrUsage},
+		{testCase: "unknown command", args: []string{"blocks"}, err: cli.ErrUsage},
+		{testCase: "unknown format", args: []string{"tx", "-format", "yaml", txHex}, err: cli.ErrUsage},
+		{testCase: "unknown flag", args: []string{"block", "-hight", "1", blockHex}, err: cli.ErrUsage},
+	}
+	for _, tc := range testCases {
+		var stdout, stderr bytes.Buffer
+		err := cli.Run(tc.args, strings.NewReader(tc.stdin), &stdout, &stderr)
+		if !errors.Is(err, tc.err) {
+			t.Errorf("test %s failed, error %v, expected %v", tc.testCase, err, tc.err)
+			continue
+		}
+		if tc.count > 0 {
+			var outputs []json.RawMessage
+			if err := json.Unmarshal(stdout.Bytes(), &outputs); err != nil || len(outputs) != tc.count {
+				t.Errorf("test %s failed, %d inscriptions in output, expected %d, error %v", tc.testCase,
+					len(outputs), tc.count, err)
+				continue
+			}
+		}
+		passed := true
+		for _, expected := range tc.contains {
+			if !strings.Contains(stdout.String(), expected) {
+				t.Errorf("test %s failed, output does not contain %q:\n%s", tc.testCase, expected, stdout.String())
+				passed = false
+			}
+		}
+		if passed {
+			t.Logf("%s: test passed", tc.testCase)
+		}
+	}
+}
+
+func TestCLIExtract(t *testing.T) {
+	t.Parallel()
+
+	script, _ := testEnvelope(txscript.NewScriptBuilder()).Script()
+	msgTx := testTransaction(script)
+	msgBlock := testBlock()
+
+	testCases := []struct {
+		testCase     string
+		args         []string
+		inscriptions []*parser.TransactionInscription
+		fromBlock    bool
+	}{
+		{
+			testCase:     "extract transaction",
+			args:         []string{testHex(msgTx.Serialize)},
+			inscriptions: parser.ParseInscriptionsFromTransaction(msgTx),
+		},
+		{
+			testCase:     "extract block at height",
+			args:         []string{"-block", "-height", "800000", testHex(msgBlock.Serialize)},
+			inscriptions: parser.ParseInscriptionsFromTransaction(msgBlock.Transactions[1]),
+			fromBlock:    true,
+		},
+	}
+	for _, tc := range testCases {
+		dir := t.TempDir()
+		var stdout, stderr bytes.Buffer
+		args := append([]string{"extract", "-o", dir}, tc.args...)
+		if err := cli.Run(args, nil, &stdout, &stderr); err != nil {
+			t.Errorf("test %s failed, error %v", tc.testCase, err)
+			continue
+		}
+		var files []struct {
+			ID          string `json:"id"`
+			BlockHeight *int32 `json:"block_height"`
+			Path        string `json:"path"`
+		}
+		if err := json.Unmarshal(stdout.Bytes(), &files); err != nil || len(files) < len(tc.inscriptions) {
+			t.Errorf("test %s failed, output %s, error %v", tc.testCase, stdout.String(), err)
+			continue
+		}
+
+		passed := true
+		for i, inscription := range tc.inscriptions {
+			path := cli.ExtractPath(dir, inscription)
+			body, err := os.ReadFile(path)
+			if files[i].ID != inscription.InscriptionID.String() || files[i].Path != path ||
+				filepath.Ext(path) != ".txt" || err != nil || string(body) != "test curses" ||
+				tc.fromBlock != (files[i].BlockHeight != nil) || (tc.fromBlock && *files[i].BlockHeight != 800000) {
+				t.Errorf("test %s failed, unexpected file %+v, body %q, error %v", tc.testCase, files[i], body, err)
+				passed = false
+			}
+		}
+		if passed {
+			t.Logf("%s: test passed", tc.testCase)
+		}
+	}
+}
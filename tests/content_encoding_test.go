@@ -0,0 +1,106 @@
+package parser
+
+import (
+	"bytes"
+	"compress/gzip"
+	"errors"
+	"testing"
+
+	"github.com/andybalholm/brotli"
+	"github.com/balletcrypto/bitcoin-inscription-parser/parser"
+)
+
+func TestDecodedBody(t *testing.T) {
+	t.Parallel()
+
+	content := bytes.Repeat([]byte("test decoded body "), 100)
+
+	var brotliBody bytes.Buffer
+	brotliWriter := brotli.NewWriter(&brotliBody)
+	_, _ = brotliWriter.Write(content)
+	_ = brotliWriter.Close()
+
+	var gzipBody bytes.Buffer
+	gzipWriter := gzip.NewWriter(&gzipBody)
+	_, _ = gzipWriter.Write(content)
+	_ = gzipWriter.Close()
+
+	var gzipBrotliBody bytes.Buffer
+	gzipWriter = gzip.NewWriter(&gzipBrotliBody)
+	_, _ = gzipWriter.Write(brotliBody.Bytes())
+	_ = gzipWriter.Close()
+
+	tests := []struct {
+		testCase string
+		encoding string
+		body     []byte
+		limit    int64
+		expected error
+	}{
+		{
+			testCase: "test body without content encoding",
+			body:     content,
+			limit:    parser.DefaultMaxDecodedBodySize,
+		},
+		{
+			testCase: "test brotli body",
+			encoding: "br",
+			body:     brotliBody.Bytes(),
+			limit:    parser.DefaultMaxDecodedBodySize,
+		},
+		{
+			testCase: "test gzip body",
+			encoding: "GZIP",
+			body:     gzipBody.Bytes(),
+			limit:    parser.DefaultMaxDecodedBodySize,
+		},
+		{
+			testCase: "test brotli then gzip body",
+			encoding: "br, gzip",
+			body:     gzipBrotliBody.Bytes(),
+			limit:    parser.DefaultMaxDecodedBodySize,
+		},
+		{
+			testCase: "test body at size limit",
+			encoding: "br",
+			body:     brotliBody.Bytes(),
+			limit:    int64(len(content)),
+		},
+		{
+			testCase: "test body over size limit",
+			encoding: "br",
+			body:     brotliBody.Bytes(),
+			limit:    int64(len(content)) - 1,
+			expected: parser.ErrDecodedBodyTooLarge,
+		},
+		{
+			testCase: "test unsupported content encoding",
+			encoding: "deflate",
+			body:     content,
+			limit:    parser.DefaultMaxDecodedBodySize,
+			expected: parser.ErrUnsupportedContentEncoding,
+		},
+	}
+
+	for _, test := range tests {
+		inscription := &parser.InscriptionContent{
+			ContentEncoding: []byte(test.encoding),
+			ContentBody:     test.body,
+		}
+		decoded, err := inscription.DecodedBodyWithLimit(test.limit)
+		switch {
+		case test.expected != nil && errors.Is(err, test.expected):
+			t.Logf("%s: test passed", test.testCase)
+		case test.expected == nil && err == nil && bytes.Equal(decoded, content):
+			t.Logf("%s: test passed", test.testCase)
+		default:
+			t.Errorf("%s: test failed, error: %v", test.testCase, err)
+		}
+	}
+
+	// Corrupt data is reported as an error rather than returned as is
+	inscription := &parser.InscriptionContent{ContentEncoding: []byte("gzip"), ContentBody: content}
+	if _, err := inscription.DecodedBody(); err == nil {
+		t.Errorf("test corrupt gzip body: test failed")
+	}
+}
@@ -0,0 +1,207 @@
+package parser
+
+import (
+	"reflect"
+	"testing"
+
+	"github.com/balletcrypto/bitcoin-inscription-parser/parser"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+func testEnvelope(builder *txscript.ScriptBuilder) *txscript.ScriptBuilder {
+	return builder.
+		AddOps([]byte{txscript.OP_FALSE, txscript.OP_IF}).
+		AddData([]byte("ord")).
+		AddOp(txscript.OP_DATA_1).
+		AddOp(txscript.OP_DATA_1).
+		AddData([]byte("text/plain;charset=utf-8")).
+		AddOp(txscript.OP_0).
+		AddData([]byte("test curses")).
+		AddOp(txscript.OP_ENDIF)
+}
+
+func testTransaction(scripts ...[]byte) *wire.MsgTx {
+	msgTx := wire.NewMsgTx(wire.TxVersion)
+	for _, script := range scripts {
+		witness := wire.TxWitness{make([]byte, 64), script, testControlBlock(0xc0, 0)}
+		msgTx.AddTxIn(wire.NewTxIn(&wire.OutPoint{}, nil, witness))
+	}
+	return msgTx
+}
+
+func TestInscriptionCurses(t *testing.T) {
+	t.Parallel()
+
+	script, _ := testEnvelope(txscript.NewScriptBuilder()).Script()
+	scriptWithTwoEnvelopes, _ := testEnvelope(testEnvelope(txscript.NewScriptBuilder())).Script()
+	scriptWithPointer, _ := txscript.NewScriptBuilder().
+		AddOps([]byte{txscript.OP_FALSE, txscript.OP_IF}).
+		AddData([]byte("ord")).
+		AddOp(txscript.OP_DATA_1).
+		AddOp(txscript.OP_DATA_2).
+		AddData([]byte{0x01, 0x02}).
+		AddOp(txscript.OP_0).
+		AddData([]byte("test curses")).
+		AddOp(txscript.OP_ENDIF).Script()
+	// A non-zero byte after the eighth can not be decoded, but the field is still there
+	scriptWithUndecodablePointer, _ := txscript.NewScriptBuilder().
+		AddOps([]byte{txscript.OP_FALSE, txscript.OP_IF}).
+		AddData([]byte("ord")).
+		AddOp(txscript.OP_DATA_1).
+		AddOp(txscript.OP_DATA_2).
+		AddData([]byte{0x01, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x00, 0x01}).
+		AddOp(txscript.OP_0).
+		AddData([]byte("test curses")).
+		AddOp(txscript.OP_ENDIF).Script()
+	scriptWithPushnum, _ := txscript.NewScriptBuilder().
+		AddOps([]byte{txscript.OP_FALSE, txscript.OP_IF}).
+		AddData([]byte("ord")).
+		AddOp(txscript.OP_1).
+		AddData([]byte("text/plain;charset=utf-8")).
+		AddOp(txscript.OP_ENDIF).Script()
+	scriptWithStutter, _ := testEnvelope(txscript.NewScriptBuilder().AddOp(txscript.OP_FALSE)).Script()
+	// The stuttering envelope fails after its header, so the next envelope does not stutter
+	scriptWithFailedStutter, _ := testEnvelope(txscript.NewScriptBuilder().
+		AddOps([]byte{txscript.OP_FALSE, txscript.OP_FALSE, txscript.OP_IF}).
+		AddData([]byte("ord")).
+		AddOp(txscript.OP_CHECKSIG).
+		AddOp(txscript.OP_ENDIF)).Script()
+	scriptWithIncompleteField, _ := txscript.NewScriptBuilder().
+		AddOps([]byte{txscript.OP_FALSE, txscript.OP_IF}).
+		AddData([]byte("ord")).
+		AddOp(txscript.OP_DATA_1).
+		AddOp(txscript.OP_DATA_1).
+		AddOp(txscript.OP_ENDIF).Script()
+	scriptWithUnrecognizedEvenField, _ := txscript.NewScriptBuilder().
+		AddOps([]byte{txscript.OP_FALSE, txscript.OP_IF}).
+		AddData([]byte("ord")).
+		AddData([]byte{0x12, 0x34}).
+		AddData([]byte("test data")).
+		AddData([]byte{0x12, 0x34}).
+		AddData([]byte("test data")).
+		AddOp(txscript.OP_ENDIF).Script()
+
+	scriptWithDuplicatedPointer, _ := txscript.NewScriptBuilder().
+		AddOps([]byte{txscript.OP_FALSE, txscript.OP_IF}).
+		AddData([]byte("ord")).
+		AddOp(txscript.OP_DATA_1).
+		AddOp(txscript.OP_DATA_2).
+		AddData([]byte{0x01, 0x02}).
+		AddOp(txscript.OP_DATA_1).
+		AddOp(txscript.OP_DATA_2).
+		AddData([]byte{0x03, 0x04}).
+		AddOp(txscript.OP_0).
+		AddData([]byte("test curses")).
+		AddOp(txscript.OP_ENDIF).Script()
+
+	tests := []struct {
+		testCase string
+		msgTx    *wire.MsgTx
+		expected [][]parser.Curse
+	}{
+		{
+			testCase: "test blessed inscription",
+			msgTx:    testTransaction(script),
+			expected: [][]parser.Curse{nil},
+		},
+		{
+			testCase: "test inscription not at offset zero",
+			msgTx:    testTransaction(scriptWithTwoEnvelopes),
+			expected: [][]parser.Curse{nil, {parser.CurseNotAtOffsetZero}},
+		},
+		{
+			testCase: "test inscription not in first input",
+			msgTx:    testTransaction(script, scriptWithTwoEnvelopes),
+			expected: [][]parser.Curse{
+				nil,
+				{parser.CurseNotInFirstInput},
+				{parser.CurseNotInFirstInput, parser.CurseNotAtOffsetZero},
+			},
+		},
+		{
+			testCase: "test inscription with pointer",
+			msgTx:    testTransaction(scriptWithPointer),
+			expected: [][]parser.Curse{{parser.CursePointer}},
+		},
+		{
+			testCase: "test inscription with undecodable pointer",
+			msgTx:    testTransaction(scriptWithUndecodablePointer),
+			expected: [][]parser.Curse{{parser.CursePointer}},
+		},
+		{
+			testCase: "test inscription with pushnum",
+			msgTx:    testTransaction(scriptWithPushnum),
+			expected: [][]parser.Curse{{parser.CursePushnum}},
+		},
+		{
+			testCase: "test inscription with stutter",
+			msgTx:    testTransaction(scriptWithStutter),
+			expected: [][]parser.Curse{{parser.CurseStutter}},
+		},
+		{
+			testCase: "test inscription after failed stuttering envelope",
+			msgTx:    testTransaction(scriptWithFailedStutter),
+			expected: [][]parser.Curse{nil},
+		},
+		{
+			testCase: "test inscription with incomplete field",
+			msgTx:    testTransaction(scriptWithIncompleteField),
+			expected: [][]parser.Curse{{parser.CurseIncompleteField}},
+		},
+		{
+			testCase: "test inscription with duplicated unrecognized even field",
+			msgTx:    testTransaction(scriptWithUnrecognizedEvenField),
+			expected: [][]parser.Curse{{parser.CurseUnrecognizedEvenField, parser.CurseDuplicateField}},
+		},
+		{
+			// Only the first pointer is taken, the second one is left over as an unrecognized even field
+			testCase: "test inscription with duplicated pointer",
+			msgTx:    testTransaction(scriptWithDuplicatedPointer),
+			expected: [][]parser.Curse{
+				{parser.CurseUnrecognizedEvenField, parser.CurseDuplicateField, parser.CursePointer},
+			},
+		},
+	}
+
+	for _, test := range tests {
+		inscriptions := parser.ParseInscriptionsFromTransaction(test.msgTx)
+		var curses [][]parser.Curse
+		for _, inscription := range inscriptions {
+			curses = append(curses, inscription.Curses)
+		}
+		if reflect.DeepEqual(curses, test.expected) {
+			t.Logf("%s: test passed", test.testCase)
+		} else {
+			t.Errorf("%s: test failed, curses: %v", test.testCase, curses)
+		}
+	}
+}
+
+func TestInscriptionJubilee(t *testing.T) {
+	t.Parallel()
+
+	inscription := &parser.TransactionInscription{
+		Inscription: &parser.InscriptionContent{},
+		Curses:      []parser.Curse{parser.CurseNotInFirstInput},
+	}
+	if !inscription.IsCursedAt(parser.MainNetJubileeHeight-1, &chaincfg.MainNetParams) {
+		t.Errorf("test inscription before jubilee: test failed")
+	}
+	if inscription.IsCursedAt(parser.MainNetJubileeHeight, &chaincfg.MainNetParams) ||
+		!inscription.IsVindicatedAt(parser.MainNetJubileeHeight, &chaincfg.MainNetParams) {
+		t.Errorf("test inscription at jubilee: test failed")
+	}
+	if inscription.IsCursedAt(parser.RegressionNetJubileeHeight, &chaincfg.RegressionNetParams) {
+		t.Errorf("test inscription at regtest jubilee: test failed")
+	}
+
+	inscription.AddCurse(parser.CurseReinscription)
+	inscription.AddCurse(parser.CurseDuplicateField)
+	inscription.AddCurse(parser.CurseReinscription)
+	expected := []parser.Curse{parser.CurseDuplicateField, parser.CurseNotInFirstInput, parser.CurseReinscription}
+	if !reflect.DeepEqual(inscription.Curses, expected) {
+		t.Errorf("test add curse: test failed, curses: %v", inscription.Curses)
+	}
+}
@@ -0,0 +1,135 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"reflect"
+	"testing"
+
+	"github.com/balletcrypto/bitcoin-inscription-parser/parser"
+	"github.com/btcsuite/btcd/txscript"
+)
+
+func TestBuildInscriptionScriptRoundTrip(t *testing.T) {
+	t.Parallel()
+
+	prefix, _ := txscript.NewScriptBuilder().
+		AddData(bytes.Repeat([]byte{0x02}, 32)).
+		AddOp(txscript.OP_CHECKSIG).Script()
+	parent, _ := parser.NewInscriptionIDFromStr(testTxID + "i1")
+	delegate, _ := parser.NewInscriptionIDFromStr(testTxID + "i0")
+	pointer := uint64(0)
+	largePointer := uint64(1) << 40
+	largeRune, _ := new(big.Int).SetString("340282366920938463463374607431768211455", 10)
+
+	tests := []struct {
+		testCase    string
+		inscription *parser.InscriptionContent
+	}{
+		{
+			testCase: "test round trip with content type and body",
+			inscription: &parser.InscriptionContent{
+				ContentType: []byte("text/plain;charset=utf-8"),
+				ContentBody: []byte("test round trip"),
+			},
+		},
+		{
+			testCase: "test round trip with single byte values",
+			inscription: &parser.InscriptionContent{
+				ContentType: []byte{0x01},
+				ContentBody: []byte{0x10},
+			},
+		},
+		{
+			testCase: "test round trip with large body and metadata",
+			inscription: &parser.InscriptionContent{
+				ContentType: []byte("image/png"),
+				ContentBody: bytes.Repeat([]byte{0xab}, 20000),
+				RawMetadata: bytes.Repeat([]byte{0xcd}, 1500),
+			},
+		},
+		{
+			testCase: "test round trip with every field",
+			inscription: &parser.InscriptionContent{
+				ContentType:     []byte("text/html"),
+				ContentBody:     []byte("<html></html>"),
+				ContentEncoding: []byte("br"),
+				Metaprotocol:    []byte("brc-20"),
+				RawMetadata:     []byte{0xa0},
+				Pointer:         &largePointer,
+				Parents:         []parser.InscriptionID{*parent, *delegate},
+				Delegate:        delegate,
+				Rune:            largeRune,
+			},
+		},
+		{
+			testCase: "test round trip with zero pointer and rune and no body",
+			inscription: &parser.InscriptionContent{
+				Pointer: &pointer,
+				Rune:    big.NewInt(0),
+			},
+		},
+	}
+
+	for _, test := range tests {
+		script, err := parser.BuildInscriptionScript(prefix, test.inscription, test.inscription)
+		if err != nil {
+			t.Errorf("%s: test failed, %v", test.testCase, err)
+			continue
+		}
+		if !bytes.HasPrefix(script, prefix) {
+			t.Errorf("%s: test failed, script does not start with the prefix", test.testCase)
+		}
+
+		inscriptions, errs := parser.ParseInscriptionsWithDiagnostics(script)
+		if len(inscriptions) != 2 || len(errs) != 0 {
+			t.Errorf("%s: test failed, found %d inscriptions, errors: %v", test.testCase, len(inscriptions), errs)
+			continue
+		}
+		expected := *test.inscription
+		expected.ContentLength = uint64(len(expected.ContentBody))
+		expected.HasPointer = expected.Pointer != nil
+		// Multiple parents and metadata chunks are reported as duplicate fields, like ord
+		expected.IsDuplicateField = len(expected.Parents) > 1 || len(expected.RawMetadata) > 520
+		for _, inscription := range inscriptions {
+			if !reflect.DeepEqual(*inscription, expected) {
+				t.Errorf("%s: test failed, unexpected parsed inscription", test.testCase)
+			}
+		}
+	}
+}
+
+func TestBuildInscriptionScriptErrors(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		testCase    string
+		inscription *parser.InscriptionContent
+		expected    error
+	}{
+		{
+			testCase:    "test content type larger than 520 bytes",
+			inscription: &parser.InscriptionContent{ContentType: make([]byte, 521)},
+			expected:    parser.ErrPushTooLarge,
+		},
+		{
+			testCase:    "test negative rune",
+			inscription: &parser.InscriptionContent{Rune: big.NewInt(-1)},
+			expected:    parser.ErrInvalidRune,
+		},
+		{
+			testCase:    "test rune larger than 128 bits",
+			inscription: &parser.InscriptionContent{Rune: new(big.Int).Lsh(big.NewInt(1), 128)},
+			expected:    parser.ErrInvalidRune,
+		},
+	}
+
+	for _, test := range tests {
+		if _, err := parser.BuildInscriptionScript(nil, test.inscription); errors.Is(err, test.expected) {
+			t.Logf("%s: test passed", test.testCase)
+		} else {
+			t.Errorf("%s: test failed, error: %v", test.testCase, err)
+		}
+	}
+}
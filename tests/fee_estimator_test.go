@@ -0,0 +1,113 @@
+package parser
+
+import (
+	"bytes"
+	"testing"
+
+	"github.com/balletcrypto/bitcoin-inscription-parser/mint"
+	"github.com/balletcrypto/bitcoin-inscription-parser/parser"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/wire"
+)
+
+func TestEstimateReveal(t *testing.T) {
+	t.Parallel()
+
+	privateKey := testPrivateKey(0x05)
+	pointer := uint64(1000)
+	parent, _ := parser.NewInscriptionIDFromStr(testTxID + "i3")
+
+	tests := []struct {
+		testCase    string
+		inscription *parser.InscriptionContent
+		destination []byte
+	}{
+		{
+			testCase: "test estimate small text inscription",
+			inscription: &parser.InscriptionContent{
+				ContentType: []byte("text/plain;charset=utf-8"),
+				ContentBody: []byte("test estimate"),
+			},
+			destination: testDestination(0x01),
+		},
+		{
+			testCase: "test estimate inscription with optional fields",
+			inscription: &parser.InscriptionContent{
+				ContentType:  []byte("application/json"),
+				ContentBody:  []byte(`{"p":"brc-20","op":"mint","tick":"ordi","amt":"1000"}`),
+				Metaprotocol: []byte("brc-20"),
+				RawMetadata:  bytes.Repeat([]byte{0xa0}, 700),
+				Pointer:      &pointer,
+				Parents:      []parser.InscriptionID{*parent},
+			},
+			destination: testDestination(0x02),
+		},
+		{
+			// Large enough for the tapscript length to need a 5-byte varint
+			testCase: "test estimate large image inscription",
+			inscription: &parser.InscriptionContent{
+				ContentType: []byte("image/png"),
+				ContentBody: bytes.Repeat([]byte{0x89}, 70000),
+			},
+			destination: []byte{0x00, 0x14, 0x01, 0x02, 0x03, 0x04, 0x05, 0x06, 0x07, 0x08, 0x09, 0x0a, 0x0b, 0x0c,
+				0x0d, 0x0e, 0x0f, 0x10, 0x11, 0x12, 0x13, 0x14},
+		},
+	}
+
+	for _, test := range tests {
+		for _, feeRate := range []parser.FeeRate{12, 1.5, 2.3} {
+			estimate, err := parser.EstimateReveal(test.inscription, feeRate, mint.DefaultPostage, len(test.destination))
+			if err != nil {
+				t.Errorf("%s at %v sat/vB: test failed, %v", test.testCase, feeRate, err)
+				continue
+			}
+
+			// Compare with the transaction the mint package actually builds and signs
+			commit, _ := mint.NewCommit(privateKey.PubKey(), &chaincfg.MainNetParams, test.inscription)
+			output := wire.NewTxOut(mint.DefaultPostage, test.destination)
+			commitOutPoint, prevOuts := testCommitTx(commit, estimate.CommitAmount)
+			revealTx, err := commit.NewRevealTx(commitOutPoint, estimate.CommitAmount, feeRate, output)
+			if err != nil {
+				t.Errorf("%s at %v sat/vB: test failed, %v", test.testCase, feeRate, err)
+				continue
+			}
+			_ = commit.SignRevealTx(revealTx, commitOutPoint, privateKey, prevOuts)
+
+			witnessWeight := int64(revealTx.TxIn[0].Witness.SerializeSize())
+			if estimate.TapscriptSize == len(commit.Script) && estimate.WitnessWeight == witnessWeight &&
+				estimate.RevealVSize == mint.VirtualSize(revealTx) &&
+				estimate.RevealFee == feeRate.Fee(estimate.RevealVSize) &&
+				estimate.RevealFee == commit.RevealFee(feeRate, output) &&
+				estimate.CommitAmount == commit.CommitAmount(feeRate, output) {
+				t.Logf("%s at %v sat/vB: test passed", test.testCase, feeRate)
+			} else {
+				t.Errorf("%s at %v sat/vB: test failed, estimate %+v, tapscript %d, witness weight %d, vsize %d",
+					test.testCase, feeRate, estimate, len(commit.Script), witnessWeight, mint.VirtualSize(revealTx))
+			}
+		}
+	}
+}
+
+func TestFeeRate(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		testCase string
+		feeRate  parser.FeeRate
+		vsize    int64
+		fee      int64
+	}{
+		{testCase: "test whole fee rate", feeRate: 12, vsize: 141, fee: 1692},
+		{testCase: "test fractional fee rate rounded up", feeRate: 1.5, vsize: 141, fee: 212},
+		{testCase: "test fractional fee rate without rounding", feeRate: 1.5, vsize: 140, fee: 210},
+		{testCase: "test fee rate below one sat per vbyte", feeRate: 0.1, vsize: 1, fee: 1},
+		{testCase: "test zero fee rate", feeRate: 0, vsize: 141, fee: 0},
+	}
+	for _, test := range tests {
+		if fee := test.feeRate.Fee(test.vsize); fee == test.fee {
+			t.Logf("%s: test passed", test.testCase)
+		} else {
+			t.Errorf("%s: test failed, fee %d, expected %d", test.testCase, fee, test.fee)
+		}
+	}
+}
@@ -0,0 +1,368 @@
+package parser
+
+import (
+	"errors"
+	"path/filepath"
+	"testing"
+
+	"github.com/balletcrypto/bitcoin-inscription-parser/indexer"
+	"github.com/balletcrypto/bitcoin-inscription-parser/parser"
+	"github.com/balletcrypto/bitcoin-inscription-parser/source"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// testBlockSource serves a chain of blocks, indexed by height.
+type testBlockSource []*wire.MsgBlock
+
+func (s testBlockSource) GetBlock(blockHash *chainhash.Hash) (*wire.MsgBlock, error) {
+	for _, msgBlock := range s {
+		if msgBlock.BlockHash() == *blockHash {
+			return msgBlock, nil
+		}
+	}
+	return nil, source.ErrNotFound
+}
+
+func (s testBlockSource) GetBlockHash(height int32) (*chainhash.Hash, error) {
+	if height < 0 || int(height) >= len(s) {
+		return nil, source.ErrNotFound
+	}
+	blockHash := s[height].BlockHash()
+	return &blockHash, nil
+}
+
+func (s testBlockSource) BestHeight() (int32, error) {
+	return int32(len(s)) - 1, nil
+}
+
+func (s testBlockSource) GetTransaction(txID *chainhash.Hash) (*wire.MsgTx, error) {
+	for _, msgBlock := range s {
+		for _, msgTx := range msgBlock.Transactions {
+			if msgTx.TxHash() == *txID {
+				return msgTx, nil
+			}
+		}
+	}
+	return nil, source.ErrNotFound
+}
+
+// testSpend spends prevOut with the script, nil for a key path spend, into outputs of the given values.
+func testSpend(prevOut wire.OutPoint, script []byte, values ...int64) *wire.MsgTx {
+	msgTx := wire.NewMsgTx(wire.TxVersion)
+	witness := wire.TxWitness{make([]byte, 64)}
+	if script != nil {
+		witness = append(witness, script, testControlBlock(0xc0, 0))
+	}
+	msgTx.AddTxIn(wire.NewTxIn(&prevOut, nil, witness))
+	for _, value := range values {
+		msgTx.AddTxOut(wire.NewTxOut(value, []byte{txscript.OP_TRUE}))
+	}
+	return msgTx
+}
+
+func testOutPoint(msgTx *wire.MsgTx, index uint32) wire.OutPoint {
+	return wire.OutPoint{Hash: msgTx.TxHash(), Index: index}
+}
+
+func testIndexerChain() (testBlockSource, map[string]*wire.MsgTx) {
+	const subsidy = 5000000000
+	script, _ := testEnvelope(txscript.NewScriptBuilder()).Script()
+	scriptWithTwoEnvelopes, _ := testEnvelope(testEnvelope(txscript.NewScriptBuilder())).Script()
+	scriptWithPointer, _ := txscript.NewScriptBuilder().
+		AddOps([]byte{txscript.OP_FALSE, txscript.OP_IF}).
+		AddData([]byte("ord")).
+		AddOp(txscript.OP_DATA_1).
+		AddOp(txscript.OP_DATA_2).
+		AddData([]byte{0x88, 0x13}).
+		AddOp(txscript.OP_ENDIF).Script()
+
+	genesis := chaincfg.RegressionNetParams.GenesisBlock
+	block1 := testChildBlock(genesis, 1)
+	block2 := testChildBlock(block1, 2)
+
+	txs := map[string]*wire.MsgTx{}
+	// Blessed, with a zero value output for the unbound inscription
+	txs["blessed"] = testSpend(testOutPoint(block1.Transactions[0], 0), script, 1000, subsidy-10000, 0)
+	// Blessed and cursed for not being at offset zero, both on the first sat
+	txs["two"] = testSpend(testOutPoint(block2.Transactions[0], 0), scriptWithTwoEnvelopes, 1000)
+	// Cursed for the pointer, which moves it to the second output
+	txs["pointer"] = testSpend(testOutPoint(txs["blessed"], 1), scriptWithPointer, 2000, subsidy-20000)
+	// Cursed reinscription of the first sat of "two", sent to fee
+	txs["fee"] = testSpend(testOutPoint(txs["two"], 0), script)
+	block3 := testChildBlock(block2, 3, txs["blessed"], txs["two"], txs["pointer"], txs["fee"])
+	// The coinbase claims the fees, so the inscription sent to fee lands in it
+	block3.Transactions[0].TxOut[0].Value = subsidy + 9000 + (subsidy - 1000) + 8000 + 1000
+
+	// Unbound, inscribed on a zero value input
+	txs["unbound"] = testSpend(testOutPoint(txs["blessed"], 2), script, 0)
+	// Sent to fee, but the coinbase does not claim it
+	txs["lost"] = testSpend(testOutPoint(txs["pointer"], 0), script)
+	block4 := testChildBlock(block3, 4, txs["unbound"], txs["lost"])
+
+	chain := testBlockSource{genesis, block1, block2, block3, block4}
+	for height := int32(len(chain)); height < parser.RegressionNetJubileeHeight; height++ {
+		chain = append(chain, testChildBlock(chain[height-1], uint32(height)))
+	}
+	// Cursed for not being at offset zero, but vindicated
+	txs["vindicated"] = testSpend(testOutPoint(chain[5].Transactions[0], 0), scriptWithTwoEnvelopes, 1000)
+	chain = append(chain, testChildBlock(chain[len(chain)-1], uint32(len(chain)), txs["vindicated"]))
+	return chain, txs
+}
+
+func TestIndexer(t *testing.T) {
+	t.Parallel()
+
+	chain, txs := testIndexerChain()
+	path := filepath.Join(t.TempDir(), "index.db")
+	index, err := indexer.Open(path, &indexer.Options{Params: &chaincfg.RegressionNetParams})
+	if err != nil {
+		t.Fatalf("test indexer failed, %v", err)
+	}
+	if err := index.Sync(chain); err != nil {
+		t.Fatalf("test indexer failed, %v", err)
+	}
+
+	satPoint := func(msgTx *wire.MsgTx, index uint32, offset uint64) string {
+		return indexer.SatPoint{OutPoint: testOutPoint(msgTx, index), Offset: offset}.String()
+	}
+	// The inscriptions of "two" are sent to fee along with the reinscription
+	feeSatPoint := satPoint(chain[3].Transactions[0], 0, 5000000000+9000+4999999000+8000)
+	expected := []struct {
+		tx         string
+		index      uint32
+		number     int32
+		satPoint   string
+		current    string
+		fee        uint64
+		curses     []parser.Curse
+		vindicated bool
+	}{
+		{"blessed", 0, 0, satPoint(txs["blessed"], 0, 0), "", 9000, nil, false},
+		{"two", 0, 1, satPoint(txs["two"], 0, 0), feeSatPoint, 4999999000 / 2, nil, false},
+		{"two", 1, -1, satPoint(txs["two"], 0, 0), feeSatPoint, 4999999000 / 2,
+			[]parser.Curse{parser.CurseNotAtOffsetZero}, false},
+		{"pointer", 0, -2, satPoint(txs["pointer"], 1, 3000), "", 8000, []parser.Curse{parser.CursePointer}, false},
+		{"fee", 0, -3, feeSatPoint, "", 1000, []parser.Curse{parser.CurseReinscription}, false},
+		{"unbound", 0, 2, indexer.SatPoint{OutPoint: indexer.UnboundOutPoint}.String(), "", 0, nil, false},
+		{"lost", 0, 3, indexer.SatPoint{OutPoint: indexer.LostOutPoint}.String(), "", 2000, nil, false},
+		{"vindicated", 0, 4, satPoint(txs["vindicated"], 0, 0), "", 4999999000 / 2, nil, false},
+		{"vindicated", 1, 5, satPoint(txs["vindicated"], 0, 0), "", 4999999000 / 2,
+			[]parser.Curse{parser.CurseNotAtOffsetZero}, true},
+	}
+
+	check := func(index *indexer.Indexer) {
+		if height, err := index.Height(); err != nil || height != int32(len(chain))-1 {
+			t.Errorf("test indexer failed, height %d, %v", height, err)
+		}
+		for sequenceNumber, e := range expected {
+			id := parser.InscriptionID{TxID: txs[e.tx].TxHash(), Index: e.index}
+			entry, err := index.InscriptionByID(id)
+			if err != nil {
+				t.Errorf("test indexer failed, %s: %v", id, err)
+				continue
+			}
+			current := e.current
+			if current == "" {
+				current = e.satPoint
+			}
+			if entry.Number != e.number || entry.SequenceNumber != uint32(sequenceNumber) ||
+				entry.GenesisSatPoint.String() != e.satPoint || entry.SatPoint.String() != current ||
+				entry.Fee != e.fee || len(entry.Curses) != len(e.curses) || entry.Vindicated != e.vindicated {
+				t.Errorf("test indexer failed, %s %s: got number %d, sequence number %d, satpoint %s, current "+
+					"satpoint %s, fee %d, curses %v", e.tx, id, entry.Number, entry.SequenceNumber,
+					entry.GenesisSatPoint, entry.SatPoint, entry.Fee, entry.Curses)
+				continue
+			}
+			for i, curse := range e.curses {
+				if entry.Curses[i] != curse {
+					t.Errorf("test indexer failed, %s: expected curses %v, got %v", id, e.curses, entry.Curses)
+				}
+			}
+			if byNumber, err := index.InscriptionByNumber(e.number); err != nil || byNumber.ID != id {
+				t.Errorf("test indexer failed, inscription number %d: %v", e.number, err)
+			}
+			if bySequence, err := index.InscriptionBySequenceNumber(uint32(sequenceNumber)); err != nil ||
+				bySequence.ID != id {
+				t.Errorf("test indexer failed, sequence number %d: %v", sequenceNumber, err)
+			}
+		}
+
+		if _, err := index.InscriptionByNumber(6); !errors.Is(err, indexer.ErrNotFound) {
+			t.Errorf("test indexer failed, expected ErrNotFound, got %v", err)
+		}
+		if _, err := index.InscriptionByID(parser.InscriptionID{}); !errors.Is(err, indexer.ErrNotFound) {
+			t.Errorf("test indexer failed, expected ErrNotFound, got %v", err)
+		}
+		if _, err := index.InscriptionBySequenceNumber(uint32(len(expected))); !errors.Is(err, indexer.ErrNotFound) {
+			t.Errorf("test indexer failed, expected ErrNotFound, got %v", err)
+		}
+	}
+	check(index)
+
+	if err := index.IndexBlock(chain[4], 4); !errors.Is(err, indexer.ErrUnexpectedBlock) {
+		t.Errorf("test indexer failed, expected ErrUnexpectedBlock, got %v", err)
+	}
+	if err := index.Close(); err != nil {
+		t.Fatalf("test indexer failed, %v", err)
+	}
+
+	// The index survives reopening, and a synced index has nothing left to do
+	index, err = indexer.Open(path, &indexer.Options{Params: &chaincfg.RegressionNetParams})
+	if err != nil {
+		t.Fatalf("test indexer failed, %v", err)
+	}
+	defer index.Close()
+	if err := index.Sync(chain); err != nil {
+		t.Fatalf("test indexer failed, %v", err)
+	}
+	check(index)
+}
+
+func TestIndexerMissingPrevOut(t *testing.T) {
+	t.Parallel()
+
+	chain, _ := testIndexerChain()
+	index, err := indexer.Open(filepath.Join(t.TempDir(), "index.db"),
+		&indexer.Options{Params: &chaincfg.RegressionNetParams})
+	if err != nil {
+		t.Fatalf("test indexer failed, %v", err)
+	}
+	defer index.Close()
+
+	// Without the earlier blocks or a PrevOutSource, the values of the spent outputs are unknown
+	if err := index.IndexBlock(chain[3], 3); !errors.Is(err, indexer.ErrMissingPrevOut) {
+		t.Errorf("test indexer failed, expected ErrMissingPrevOut, got %v", err)
+	}
+	if height, err := index.Height(); err != nil || height != -1 {
+		t.Errorf("test indexer failed, expected an empty index, got height %d, %v", height, err)
+	}
+}
+
+func TestIndexerFirstInscriptionHeight(t *testing.T) {
+	t.Parallel()
+
+	chain, txs := testIndexerChain()
+	index, err := indexer.Open(filepath.Join(t.TempDir(), "index.db"),
+		&indexer.Options{Params: &chaincfg.RegressionNetParams, FirstInscriptionHeight: 3})
+	if err != nil {
+		t.Fatalf("test indexer first inscription height failed, %v", err)
+	}
+	defer index.Close()
+
+	// Blocks before the first inscription height are skipped, the values of their outputs come from the source
+	if err := index.Sync(chain); err != nil {
+		t.Fatalf("test indexer first inscription height failed, %v", err)
+	}
+	if height, err := index.Height(); err != nil || height != int32(len(chain))-1 {
+		t.Errorf("test indexer first inscription height failed, height %d, %v", height, err)
+	}
+	entry, err := index.InscriptionByNumber(0)
+	if err != nil || entry.ID.TxID != txs["blessed"].TxHash() || entry.Height != 3 || entry.Fee != 9000 {
+		t.Errorf("test indexer first inscription height failed, got %+v, %v", entry, err)
+	}
+	if height := indexer.FirstInscriptionHeight(&chaincfg.MainNetParams); height != 767430 {
+		t.Errorf("test indexer first inscription height failed, mainnet height %d", height)
+	}
+}
+
+func TestIndexerTransfers(t *testing.T) {
+	t.Parallel()
+
+	const subsidy = 5000000000
+	script, _ := testEnvelope(txscript.NewScriptBuilder()).Script()
+	genesis := chaincfg.RegressionNetParams.GenesisBlock
+	block1 := testChildBlock(genesis, 1)
+	reveal := testSpend(testOutPoint(block1.Transactions[0], 0), script, 1000, 2000)
+	block2 := testChildBlock(block1, 2, reveal)
+
+	// Spending the second output first puts the inscription 2000 sats into the inputs, so in the second output
+	transfer := testSpend(testOutPoint(reveal, 1), nil, 1500, 1500)
+	revealOutPoint := testOutPoint(reveal, 0)
+	transfer.AddTxIn(wire.NewTxIn(&revealOutPoint, nil, wire.TxWitness{make([]byte, 64)}))
+	// The inscription is at offset 500 of an input of 1500 sats, beyond the single output of 400 sats
+	toFee := testSpend(testOutPoint(transfer, 1), nil, 400)
+	block3 := testChildBlock(block2, 3, transfer, toFee)
+	block3.Transactions[0].TxOut[0].Value = subsidy + 1100
+	// The coinbase got the inscription at offset subsidy + 500 - 400
+	fromCoinbase := testSpend(testOutPoint(block3.Transactions[0], 0), nil, subsidy+100, 1000)
+	block4 := testChildBlock(block3, 4, fromCoinbase)
+	chain := testBlockSource{genesis, block1, block2, block3, block4}
+
+	index, err := indexer.Open(filepath.Join(t.TempDir(), "index.db"),
+		&indexer.Options{Params: &chaincfg.RegressionNetParams})
+	if err != nil {
+		t.Fatalf("test indexer transfers failed, %v", err)
+	}
+	defer index.Close()
+
+	id := parser.InscriptionID{TxID: reveal.TxHash()}
+	expected := []struct {
+		height   int32
+		satPoint indexer.SatPoint
+	}{
+		{2, indexer.SatPoint{OutPoint: testOutPoint(reveal, 0)}},
+		{3, indexer.SatPoint{OutPoint: testOutPoint(block3.Transactions[0], 0), Offset: subsidy + 100}},
+		{4, indexer.SatPoint{OutPoint: testOutPoint(fromCoinbase, 1), Offset: 0}},
+	}
+	for _, e := range expected {
+		if err := index.Sync(chain[:e.height+1]); err != nil {
+			t.Fatalf("test indexer transfers failed, %v", err)
+		}
+		entry, err := index.InscriptionByID(id)
+		if err != nil {
+			t.Fatalf("test indexer transfers failed, %v", err)
+		}
+		if entry.SatPoint != e.satPoint || entry.GenesisSatPoint != expected[0].satPoint {
+			t.Errorf("test indexer transfers failed, height %d: expected satpoint %s, got %s", e.height,
+				e.satPoint, entry.SatPoint)
+		}
+		entries, err := index.InscriptionsOnOutput(e.satPoint.OutPoint)
+		if err != nil || len(entries) != 1 || entries[0].ID != id {
+			t.Errorf("test indexer transfers failed, height %d: expected the inscription on %s, got %d, %v",
+				e.height, e.satPoint.OutPoint, len(entries), err)
+		}
+	}
+
+	if entries, err := index.InscriptionsOnOutput(testOutPoint(reveal, 0)); err != nil || len(entries) != 0 {
+		t.Errorf("test indexer transfers failed, expected no inscription left on the reveal output, got %d, %v",
+			len(entries), err)
+	}
+}
+
+func TestIndexerSubsidy(t *testing.T) {
+	t.Parallel()
+
+	const subsidy = 5000000000
+	script, _ := testEnvelope(txscript.NewScriptBuilder()).Script()
+	genesis := chaincfg.RegressionNetParams.GenesisBlock
+	chain := testBlockSource{genesis, testChildBlock(genesis, 1)}
+	// Regtest halves the subsidy every 150 blocks, ord keeps the mainnet schedule
+	const height = 200
+	for len(chain) < height {
+		chain = append(chain, testChildBlock(chain[len(chain)-1], uint32(len(chain))))
+	}
+	// Sent to fee, so it lands in the coinbase after the subsidy
+	reveal := testSpend(testOutPoint(chain[1].Transactions[0], 0), script)
+	block := testChildBlock(chain[height-1], height, reveal)
+	block.Transactions[0].TxOut[0].Value = 2 * subsidy
+	chain = append(chain, block)
+
+	index, err := indexer.Open(filepath.Join(t.TempDir(), "index.db"),
+		&indexer.Options{Params: &chaincfg.RegressionNetParams, FirstInscriptionHeight: height})
+	if err != nil {
+		t.Fatalf("test indexer subsidy failed, %v", err)
+	}
+	defer index.Close()
+	if err := index.Sync(chain); err != nil {
+		t.Fatalf("test indexer subsidy failed, %v", err)
+	}
+
+	entry, err := index.InscriptionByID(parser.InscriptionID{TxID: reveal.TxHash()})
+	expected := indexer.SatPoint{OutPoint: testOutPoint(block.Transactions[0], 0), Offset: subsidy}
+	if err != nil || entry.SatPoint != expected || entry.Fee != subsidy {
+		t.Errorf("test indexer subsidy failed, expected satpoint %s, got %+v, %v", expected, entry, err)
+	}
+}
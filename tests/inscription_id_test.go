@@ -0,0 +1,129 @@
+package parser
+
+import (
+	"encoding/json"
+	"testing"
+
+	"github.com/balletcrypto/bitcoin-inscription-parser/parser"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+const testTxID = "fe76628c921e7894e4f34f036cd081fc4b21009639d6f4fc12577f59818b35b8"
+
+func TestInscriptionIDEncoding(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		testCase string
+		id       string
+		compact  int
+	}{
+		{
+			testCase: "test inscription ID with zero index",
+			id:       testTxID + "i0",
+			compact:  32,
+		},
+		{
+			testCase: "test inscription ID with one byte index",
+			id:       testTxID + "i255",
+			compact:  33,
+		},
+		{
+			testCase: "test inscription ID with two byte index",
+			id:       testTxID + "i256",
+			compact:  34,
+		},
+		{
+			testCase: "test inscription ID with four byte index",
+			id:       testTxID + "i4294967295",
+			compact:  36,
+		},
+	}
+
+	for _, test := range tests {
+		id, err := parser.NewInscriptionIDFromStr(test.id)
+		if err != nil {
+			t.Errorf("%s: test failed, %v", test.testCase, err)
+			continue
+		}
+		if id.String() != test.id {
+			t.Errorf("%s: test failed, string round trip %s", test.testCase, id.String())
+		}
+
+		compact := id.Bytes()
+		fromCompact, err := parser.NewInscriptionIDFromBytes(compact)
+		if len(compact) != test.compact || err != nil || *fromCompact != *id {
+			t.Errorf("%s: test failed, compact round trip %x", test.testCase, compact)
+		}
+
+		binary, _ := id.MarshalBinary()
+		var fromBinary parser.InscriptionID
+		if err := fromBinary.UnmarshalBinary(binary); len(binary) != parser.InscriptionIDSize || err != nil ||
+			fromBinary != *id {
+			t.Errorf("%s: test failed, binary round trip %x", test.testCase, binary)
+		}
+		// Parent and delegate tags may also hold the fixed 36-byte form, whose index can end in zero bytes
+		if fromTag, err := parser.NewInscriptionIDFromBytes(binary); err != nil || *fromTag != *id {
+			t.Errorf("%s: test failed, tag round trip %x, %v", test.testCase, binary, err)
+		}
+
+		encoded, _ := json.Marshal(id)
+		var fromJSON parser.InscriptionID
+		if err := json.Unmarshal(encoded, &fromJSON); err != nil || string(encoded) != `"`+test.id+`"` ||
+			fromJSON != *id {
+			t.Errorf("%s: test failed, JSON round trip %s", test.testCase, encoded)
+		}
+	}
+}
+
+func TestInvalidInscriptionID(t *testing.T) {
+	t.Parallel()
+
+	for _, s := range []string{"", testTxID, testTxID + "i", testTxID + "i-1", testTxID + "i4294967296", "00i0"} {
+		if _, err := parser.NewInscriptionIDFromStr(s); err == nil {
+			t.Errorf("test invalid inscription ID %q: test failed", s)
+		}
+	}
+	// An index shorter than 4 bytes must not end in a zero byte
+	indexWithTrailingZero := append(make([]byte, 32), 0x01, 0x00)
+	for _, value := range [][]byte{make([]byte, 31), make([]byte, 33), make([]byte, 37), indexWithTrailingZero} {
+		if _, err := parser.NewInscriptionIDFromBytes(value); err == nil {
+			t.Errorf("test invalid inscription ID bytes %x: test failed", value)
+		}
+	}
+}
+
+func TestInscriptionIDFromTransaction(t *testing.T) {
+	script, _ := txscript.NewScriptBuilder().
+		AddOps([]byte{txscript.OP_FALSE, txscript.OP_IF}).
+		AddData([]byte("ord")).
+		AddOp(txscript.OP_0).
+		AddData([]byte("test inscription ID from transaction")).
+		AddOp(txscript.OP_ENDIF).
+		AddOps([]byte{txscript.OP_FALSE, txscript.OP_IF}).
+		AddData([]byte("ord")).
+		AddOp(txscript.OP_0).
+		AddData([]byte("test inscription ID from transaction")).
+		AddOp(txscript.OP_ENDIF).
+		Script()
+	controlBlock := testControlBlock(byte(txscript.BaseLeafVersion), 0)
+
+	msgTx := wire.NewMsgTx(wire.TxVersion)
+	for i := 0; i < 2; i++ {
+		txIn := wire.NewTxIn(&wire.OutPoint{}, nil, wire.TxWitness{make([]byte, 64), script, controlBlock})
+		msgTx.AddTxIn(txIn)
+	}
+
+	inscriptions := parser.ParseInscriptionsFromTransaction(msgTx)
+	if len(inscriptions) != 4 {
+		t.Fatalf("test inscription ID from transaction: expected 4 inscriptions, got %d", len(inscriptions))
+	}
+	for i, inscription := range inscriptions {
+		if inscription.InscriptionID.TxID != msgTx.TxHash() || inscription.InscriptionID.Index != uint32(i) ||
+			inscription.TxInIndex != uint32(i/2) || inscription.TxInOffset != uint64(i%2) {
+			t.Errorf("test inscription ID from transaction: unexpected inscription %d: %s, input %d, offset %d", i,
+				inscription.InscriptionID, inscription.TxInIndex, inscription.TxInOffset)
+		}
+	}
+}
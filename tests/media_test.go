@@ -0,0 +1,34 @@
+package parser
+
+import (
+	"testing"
+
+	"github.com/balletcrypto/bitcoin-inscription-parser/parser"
+)
+
+func TestFileExtension(t *testing.T) {
+	t.Parallel()
+
+	tests := []struct {
+		contentType string
+		expected    string
+	}{
+		{contentType: "text/plain;charset=utf-8", expected: "txt"},
+		{contentType: "image/png", expected: "png"},
+		{contentType: "IMAGE/JPEG", expected: "jpg"},
+		{contentType: "text/html; charset=utf-8", expected: "html"},
+		{contentType: "image/svg+xml", expected: "svg"},
+		{contentType: "application/json", expected: "json"},
+		{contentType: "text/plain;;", expected: "txt"},
+		{contentType: "application/x-unknown", expected: parser.DefaultFileExtension},
+		{contentType: "", expected: parser.DefaultFileExtension},
+	}
+
+	for _, test := range tests {
+		inscription := &parser.InscriptionContent{ContentType: []byte(test.contentType)}
+		if extension := inscription.FileExtension(); extension != test.expected {
+			t.Errorf("test file extension of %q failed, expected %s, got %s", test.contentType, test.expected,
+				extension)
+		}
+	}
+}
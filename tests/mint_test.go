@@ -0,0 +1,113 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/balletcrypto/bitcoin-inscription-parser/mint"
+	"github.com/balletcrypto/bitcoin-inscription-parser/parser"
+	"github.com/btcsuite/btcd/btcec/v2"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+func testPrivateKey(seed byte) *btcec.PrivateKey {
+	privateKey, _ := btcec.PrivKeyFromBytes(bytes.Repeat([]byte{seed}, 32))
+	return privateKey
+}
+
+// testCommitTx funds the commit output from a fake previous transaction.
+func testCommitTx(commit *mint.Commit, amount int64) (wire.OutPoint, map[wire.OutPoint]*wire.TxOut) {
+	commitTx := wire.NewMsgTx(2)
+	commitTx.AddTxIn(wire.NewTxIn(&wire.OutPoint{Index: 1}, nil, nil))
+	commitTx.AddTxOut(commit.TxOut(amount))
+	commitOutPoint := wire.OutPoint{Hash: commitTx.TxHash(), Index: 0}
+	return commitOutPoint, map[wire.OutPoint]*wire.TxOut{commitOutPoint: commitTx.TxOut[0]}
+}
+
+// testVerifyInput runs the script engine on an input of the transaction.
+func testVerifyInput(t *testing.T, msgTx *wire.MsgTx, index int, prevOuts map[wire.OutPoint]*wire.TxOut) {
+	prevOutFetcher := txscript.NewMultiPrevOutFetcher(prevOuts)
+	prevOut := prevOuts[msgTx.TxIn[index].PreviousOutPoint]
+	engine, err := txscript.NewEngine(prevOut.PkScript, msgTx, index, txscript.StandardVerifyFlags, nil,
+		txscript.NewTxSigHashes(msgTx, prevOutFetcher), prevOut.Value, prevOutFetcher)
+	if err != nil {
+		t.Fatalf("create script engine: %v", err)
+	}
+	if err := engine.Execute(); err != nil {
+		t.Errorf("input %d does not verify: %v", index, err)
+	}
+}
+
+func TestMintRevealTransaction(t *testing.T) {
+	t.Parallel()
+
+	privateKey := testPrivateKey(0x01)
+	inscription := &parser.InscriptionContent{
+		ContentType: []byte("text/plain;charset=utf-8"),
+		ContentBody: bytes.Repeat([]byte("test mint reveal transaction "), 100),
+	}
+	commit, err := mint.NewCommit(privateKey.PubKey(), &chaincfg.RegressionNetParams, inscription)
+	if err != nil {
+		t.Fatalf("test mint reveal transaction: %v", err)
+	}
+	if commit.Address.EncodeAddress()[:6] != "bcrt1p" {
+		t.Errorf("test mint reveal transaction: unexpected commit address %s", commit.Address.EncodeAddress())
+	}
+
+	destination := []byte{txscript.OP_1, txscript.OP_DATA_32}
+	destination = append(destination, bytes.Repeat([]byte{0x42}, 32)...)
+	output := wire.NewTxOut(mint.DefaultPostage, destination)
+	feeRate := parser.FeeRate(10)
+	commitAmount := commit.CommitAmount(feeRate, output)
+	commitOutPoint, prevOuts := testCommitTx(commit, commitAmount)
+
+	if _, err := commit.NewRevealTx(commitOutPoint, commitAmount-1, feeRate, output); !errors.Is(err,
+		mint.ErrInsufficientCommitAmount) {
+		t.Errorf("test mint reveal transaction: insufficient commit amount not detected")
+	}
+	revealTx, err := commit.NewRevealTx(commitOutPoint, commitAmount, feeRate, output)
+	if err != nil {
+		t.Fatalf("test mint reveal transaction: %v", err)
+	}
+	if revealTx.HasWitness() {
+		t.Errorf("test mint reveal transaction: unsigned reveal transaction has a witness")
+	}
+	if err := commit.SignRevealTx(revealTx, commitOutPoint, privateKey, prevOuts); err != nil {
+		t.Fatalf("test mint reveal transaction: %v", err)
+	}
+	testVerifyInput(t, revealTx, 0, prevOuts)
+
+	// The fee estimate uses a signature of the same size, so the actual fee rate matches the requested one
+	fee := commitAmount - mint.DefaultPostage
+	if fee != feeRate.Fee(mint.VirtualSize(revealTx)) {
+		t.Errorf("test mint reveal transaction: fee %d does not match vsize %d", fee, mint.VirtualSize(revealTx))
+	}
+
+	inscriptions := parser.ParseInscriptionsFromTransaction(revealTx)
+	if len(inscriptions) != 1 || !bytes.Equal(inscriptions[0].Inscription.ContentBody, inscription.ContentBody) ||
+		inscriptions[0].IsCursed() {
+		t.Errorf("test mint reveal transaction: inscription not found in reveal transaction")
+	}
+}
+
+func TestMintSignRevealTransactionErrors(t *testing.T) {
+	t.Parallel()
+
+	privateKey := testPrivateKey(0x02)
+	commit, _ := mint.NewCommit(privateKey.PubKey(), &chaincfg.MainNetParams, &parser.InscriptionContent{
+		ContentBody: []byte("test sign errors"),
+	})
+	commitOutPoint, prevOuts := testCommitTx(commit, 100000)
+	revealTx, _ := commit.NewRevealTx(commitOutPoint, 100000, 1, wire.NewTxOut(mint.DefaultPostage, commit.PkScript))
+
+	if err := commit.SignRevealTx(revealTx, wire.OutPoint{Index: 7}, privateKey, prevOuts); !errors.Is(err,
+		mint.ErrCommitInputNotFound) {
+		t.Errorf("test sign reveal transaction without commit input: test failed, %v", err)
+	}
+	if err := commit.SignRevealTx(revealTx, commitOutPoint, privateKey, nil); err == nil {
+		t.Errorf("test sign reveal transaction without previous outputs: test failed")
+	}
+}
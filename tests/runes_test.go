@@ -0,0 +1,332 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"math/big"
+	"testing"
+
+	"github.com/balletcrypto/bitcoin-inscription-parser/parser"
+	"github.com/balletcrypto/bitcoin-inscription-parser/runes"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// testRunestoneTx returns a transaction with the given output scripts after a pay-to-taproot output.
+func testRunestoneTx(witness wire.TxWitness, scripts ...[]byte) *wire.MsgTx {
+	msgTx := wire.NewMsgTx(wire.TxVersion)
+	msgTx.AddTxIn(wire.NewTxIn(&wire.OutPoint{}, nil, witness))
+	msgTx.AddTxOut(wire.NewTxOut(10000, append([]byte{txscript.OP_1, txscript.OP_DATA_32}, make([]byte, 32)...)))
+	for _, script := range scripts {
+		msgTx.AddTxOut(wire.NewTxOut(0, script))
+	}
+	return msgTx
+}
+
+// testRunestoneScript returns a runestone output script pushing each payload.
+func testRunestoneScript(payloads ...[]byte) []byte {
+	builder := txscript.NewScriptBuilder().AddOps([]byte{txscript.OP_RETURN, runes.MagicNumber})
+	for _, payload := range payloads {
+		builder.AddFullData(payload)
+	}
+	script, _ := builder.Script()
+	return script
+}
+
+func testMaxUint128() *big.Int {
+	return new(big.Int).Sub(new(big.Int).Lsh(big.NewInt(1), 128), big.NewInt(1))
+}
+
+func testRune(t *testing.T, name string) *big.Int {
+	runeValue, _, err := runes.ParseRune(name)
+	if err != nil {
+		t.Fatalf("test parse rune %s failed, error: %v", name, err)
+	}
+	return runeValue
+}
+
+func TestRuneNames(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		value   string
+		spacers uint32
+		name    string
+	}{
+		{"0", 0, "A"},
+		{"25", 0, "Z"},
+		{"26", 0, "AA"},
+		{"701", 0, "ZZ"},
+		{"702", 0, "AAA"},
+		{"2055900680524219742", 128, "UNCOMMON•GOODS"},
+		{"2055900680524219742", 0b1_0000_0010, "UN•COMMONG•OODS"},
+		{"6402364363415443603228541259936211926", 0, "AAAAAAAAAAAAAAAAAAAAAAAAAAA"},
+		{"340282366920938463463374607431768211455", 0, "BCGDENLQRQWDSLRUGSNLBTMFIJAV"},
+	}
+	for _, testCase := range testCases {
+		value, _ := new(big.Int).SetString(testCase.value, 10)
+		if name := runes.FormatRune(value, testCase.spacers); name != testCase.name {
+			t.Errorf("test rune %s failed, expected name %s, got %s", testCase.value, testCase.name, name)
+		}
+		parsed, spacers, err := runes.ParseRune(testCase.name)
+		if err != nil || parsed.Cmp(value) != 0 || spacers != testCase.spacers {
+			t.Errorf("test parse rune %s failed, got %v, spacers %d, error: %v", testCase.name, parsed, spacers, err)
+		}
+	}
+	// Spacers can also be written as dots
+	if value, spacers, err := runes.ParseRune("UNCOMMON.GOODS"); err != nil || value.String() != "2055900680524219742" ||
+		spacers != 128 {
+		t.Errorf("test parse rune with dot failed, got %v, spacers %d, error: %v", value, spacers, err)
+	}
+	for _, invalid := range []string{"", "a", "A B", "•A", "A•", "A••B", "BCGDENLQRQWDSLRUGSNLBTMFIJAW"} {
+		if _, _, err := runes.ParseRune(invalid); !errors.Is(err, runes.ErrInvalidRuneName) {
+			t.Errorf("test parse rune failed, expected ErrInvalidRuneName for %q, got %v", invalid, err)
+		}
+	}
+
+	if !runes.IsReserved(runes.ReservedRune(runes.RuneID{Block: 840000, Tx: 1})) ||
+		runes.IsReserved(testRune(t, "ZZZZZZZZZZZZZZZZZZZZZZZZZZ")) {
+		t.Errorf("test reserved runes failed")
+	}
+	if commitment := runes.Commitment(big.NewInt(0x0102)); !bytes.Equal(commitment, []byte{0x02, 0x01}) {
+		t.Errorf("test rune commitment failed, got %x", commitment)
+	}
+}
+
+func TestRuneMinimumAtHeight(t *testing.T) {
+	t.Parallel()
+
+	testCases := []struct {
+		params  *chaincfg.Params
+		height  int32
+		minimum string
+	}{
+		{&chaincfg.MainNetParams, 0, "AAAAAAAAAAAAA"},
+		{&chaincfg.MainNetParams, 839999, "AAAAAAAAAAAAA"},
+		{&chaincfg.MainNetParams, 840000, "ZZYZXBRKWXVA"},
+		{&chaincfg.MainNetParams, 840001, "ZZXZUDIVTVQA"},
+		{&chaincfg.MainNetParams, 857499, "AAAAAAAAAAAA"},
+		{&chaincfg.MainNetParams, 857500, "ZZYZXBRKWXV"},
+		{&chaincfg.MainNetParams, 1049999, "A"},
+		{&chaincfg.MainNetParams, 1050000, "A"},
+		{&chaincfg.RegressionNetParams, 0, "ZZYZXBRKWXVA"},
+	}
+	for _, testCase := range testCases {
+		minimum := runes.FormatRune(runes.MinimumAtHeight(testCase.height, testCase.params), 0)
+		if minimum != testCase.minimum {
+			t.Errorf("test minimum rune at height %d failed, expected %s, got %s", testCase.height,
+				testCase.minimum, minimum)
+		}
+	}
+}
+
+func TestDecipherRunestone(t *testing.T) {
+	t.Parallel()
+
+	divisibility := uint8(2)
+	spacers := uint32(128)
+	symbol := '¢'
+	heightStart, offsetEnd := uint64(840000), uint64(1000)
+	pointer := uint32(0)
+	runestone := &runes.Runestone{
+		Edicts: []runes.Edict{
+			{ID: runes.RuneID{Block: 840000, Tx: 7}, Amount: big.NewInt(500), Output: 0},
+			{ID: runes.RuneID{Block: 1, Tx: 0}, Amount: big.NewInt(1), Output: 2},
+			{ID: runes.RuneID{Block: 840000, Tx: 3}, Amount: testMaxUint128(), Output: 1},
+		},
+		Etching: &runes.Etching{
+			Divisibility: &divisibility,
+			Premine:      big.NewInt(1000000),
+			Rune:         testRune(t, "UNCOMMONGOODS"),
+			Spacers:      &spacers,
+			Symbol:       &symbol,
+			Terms: &runes.Terms{
+				Amount:      big.NewInt(100),
+				Cap:         big.NewInt(10000),
+				HeightStart: &heightStart,
+				OffsetEnd:   &offsetEnd,
+			},
+			Turbo: true,
+		},
+		Mint:    &runes.RuneID{Block: 840000, Tx: 3},
+		Pointer: &pointer,
+	}
+	script := runestone.Encipher()
+	msgTx := testRunestoneTx(nil, script)
+	deciphered := runes.Decipher(msgTx)
+	if deciphered == nil || deciphered.IsCenotaph() {
+		t.Fatalf("test decipher runestone failed, got %+v", deciphered)
+	}
+	if !bytes.Equal(deciphered.Encipher(), script) {
+		t.Errorf("test decipher runestone failed, enciphered %x, got %x", script, deciphered.Encipher())
+	}
+	etching := deciphered.Etching
+	if etching == nil || runes.FormatRune(etching.Rune, *etching.Spacers) != "UNCOMMON•GOODS" ||
+		*etching.Divisibility != 2 || *etching.Symbol != '¢' || !etching.Turbo || etching.Terms == nil ||
+		etching.Terms.HeightEnd != nil || *etching.Terms.OffsetEnd != 1000 {
+		t.Errorf("test decipher runestone failed, got etching %+v", etching)
+	}
+	if supply, ok := etching.Supply(); !ok || supply.String() != "2000000" {
+		t.Errorf("test etching supply failed, got %v, %t", supply, ok)
+	}
+	// Edicts are sorted by rune ID to be delta encoded
+	if len(deciphered.Edicts) != 3 || deciphered.Edicts[0].ID.String() != "1:0" ||
+		deciphered.Edicts[2].ID.String() != "840000:7" || deciphered.Edicts[1].Amount.Cmp(testMaxUint128()) != 0 {
+		t.Errorf("test decipher runestone failed, got edicts %+v", deciphered.Edicts)
+	}
+	if *deciphered.Mint != runestone.Edicts[2].ID || *deciphered.Pointer != 0 {
+		t.Errorf("test decipher runestone failed, got mint %v, pointer %d", deciphered.Mint, *deciphered.Pointer)
+	}
+
+	// Only the first runestone output counts, and pushes are concatenated
+	msgTx = testRunestoneTx(nil, testRunestoneScript([]byte{0x02, 0x01}, []byte{0x04, 0x05}),
+		testRunestoneScript([]byte{0x18, 0x01}))
+	if deciphered := runes.Decipher(msgTx); deciphered == nil || deciphered.IsCenotaph() ||
+		deciphered.Etching == nil || deciphered.Etching.Rune.Int64() != 5 {
+		t.Errorf("test decipher runestone with multiple pushes failed, got %+v", deciphered)
+	}
+	noRunestone, _ := txscript.NewScriptBuilder().AddOp(txscript.OP_RETURN).AddData([]byte{0x02, 0x01}).Script()
+	if deciphered := runes.Decipher(testRunestoneTx(nil, noRunestone)); deciphered != nil {
+		t.Errorf("test decipher without runestone failed, got %+v", deciphered)
+	}
+}
+
+func TestDecipherCenotaph(t *testing.T) {
+	t.Parallel()
+
+	maxVarint := append(bytes.Repeat([]byte{0xff}, 18), 0x03)
+	overflowVarint := append(bytes.Repeat([]byte{0xff}, 18), 0x04)
+	overlongVarint := append(bytes.Repeat([]byte{0x80}, 19), 0x00)
+	// 2^70 is even but does not fit in 64 bits
+	largeTag := append(bytes.Repeat([]byte{0x80}, 10), 0x01)
+	testCases := []struct {
+		testCase string
+		script   []byte
+		flaw     runes.Flaw
+	}{
+		{"empty runestone", testRunestoneScript(), runes.NoFlaw},
+		{"unrecognized odd tag", testRunestoneScript([]byte{0x19, 0x01}), runes.NoFlaw},
+		{"invalid divisibility", testRunestoneScript([]byte{0x02, 0x01, 0x01, 39}), runes.NoFlaw},
+		{"edict to every output", testRunestoneScript([]byte{0x00, 0x01, 0x00, 0x05, 0x02}), runes.NoFlaw},
+		{"largest varint", testRunestoneScript(append([]byte{0x19}, maxVarint...)), runes.NoFlaw},
+		{"opcode", append(testRunestoneScript(), txscript.OP_1), runes.FlawOpcode},
+		{"invalid script", append(testRunestoneScript(), txscript.OP_DATA_5, 0x01), runes.FlawInvalidScript},
+		{"unterminated varint", testRunestoneScript([]byte{0x80}), runes.FlawVarint},
+		{"overflowing varint", testRunestoneScript(append([]byte{0x19}, overflowVarint...)), runes.FlawVarint},
+		{"overlong varint", testRunestoneScript(append([]byte{0x19}, overlongVarint...)), runes.FlawVarint},
+		{"truncated field", testRunestoneScript([]byte{0x19}), runes.FlawTruncatedField},
+		{"unrecognized even tag", testRunestoneScript([]byte{0x18, 0x01}), runes.FlawUnrecognizedEvenTag},
+		{"cenotaph tag", testRunestoneScript([]byte{0x7e, 0x00}), runes.FlawUnrecognizedEvenTag},
+		{"large even tag", testRunestoneScript(append(largeTag, 0x01)), runes.FlawUnrecognizedEvenTag},
+		{"duplicate flags", testRunestoneScript([]byte{0x02, 0x01, 0x02, 0x01}), runes.FlawUnrecognizedEvenTag},
+		{"rune without etching", testRunestoneScript([]byte{0x04, 0x05}), runes.FlawUnrecognizedEvenTag},
+		{"invalid mint", testRunestoneScript([]byte{0x14, 0x00, 0x14, 0x01}), runes.FlawUnrecognizedEvenTag},
+		{"pointer out of range", testRunestoneScript([]byte{0x16, 0x02}), runes.FlawUnrecognizedEvenTag},
+		{"unrecognized flag", testRunestoneScript([]byte{0x02, 0x08}), runes.FlawUnrecognizedFlag},
+		{"terms without etching", testRunestoneScript([]byte{0x02, 0x02}), runes.FlawUnrecognizedFlag},
+		{"edict output", testRunestoneScript([]byte{0x00, 0x01, 0x00, 0x05, 0x03}), runes.FlawEdictOutput},
+		{"edict rune ID", testRunestoneScript([]byte{0x00, 0x00, 0x01, 0x05, 0x00}), runes.FlawEdictRuneID},
+		{"trailing integers", testRunestoneScript([]byte{0x00, 0x01, 0x00, 0x05}), runes.FlawTrailingIntegers},
+		{
+			"supply overflow",
+			(&runes.Runestone{Etching: &runes.Etching{
+				Premine: testMaxUint128(),
+				Terms:   &runes.Terms{Amount: big.NewInt(1), Cap: big.NewInt(1)},
+			}}).Encipher(),
+			runes.FlawSupplyOverflow,
+		},
+	}
+	for _, testCase := range testCases {
+		deciphered := runes.Decipher(testRunestoneTx(nil, testCase.script))
+		if deciphered == nil || deciphered.Flaw != testCase.flaw {
+			t.Errorf("test %s failed, expected flaw %s, got %+v", testCase.testCase, testCase.flaw, deciphered)
+			continue
+		}
+		t.Logf("test %s: test passed", testCase.testCase)
+	}
+
+	// A cenotaph keeps the etched rune and the mint, but drops everything else
+	pointer := uint32(0)
+	cenotaph := (&runes.Runestone{
+		Etching: &runes.Etching{Rune: big.NewInt(1000), Premine: big.NewInt(5)},
+		Mint:    &runes.RuneID{Block: 1},
+		Pointer: &pointer,
+	}).Encipher()
+	cenotaph = append(cenotaph, txscript.OP_DATA_2, 0x18, 0x01)
+	deciphered := runes.Decipher(testRunestoneTx(nil, cenotaph))
+	if deciphered == nil || !deciphered.IsCenotaph() || deciphered.Pointer != nil || deciphered.Mint == nil ||
+		deciphered.Etching == nil || deciphered.Etching.Rune.Int64() != 1000 || deciphered.Etching.Premine != nil {
+		t.Errorf("test cenotaph failed, got %+v", deciphered)
+	}
+}
+
+func TestValidateEtching(t *testing.T) {
+	t.Parallel()
+
+	uncommonGoods := testRune(t, "UNCOMMONGOODS")
+	prefix := append([]byte{txscript.OP_DATA_32}, append(bytes.Repeat([]byte{0x02}, 32), txscript.OP_CHECKSIG)...)
+	envelope := func(runeValue *big.Int) wire.TxWitness {
+		script, err := parser.BuildInscriptionScript(prefix, &parser.InscriptionContent{
+			ContentType: []byte("text/plain;charset=utf-8"),
+			ContentBody: []byte("test rune commitment"),
+			Rune:        runeValue,
+		})
+		if err != nil {
+			t.Fatalf("test build envelope failed, error: %v", err)
+		}
+		return wire.TxWitness{make([]byte, 64), script, testControlBlock(0xc0, 0)}
+	}
+	etching := func(runeValue *big.Int) []byte {
+		return (&runes.Runestone{Etching: &runes.Etching{Rune: runeValue}}).Encipher()
+	}
+	withoutEnvelope, _ := txscript.NewScriptBuilder().
+		AddData(runes.Commitment(uncommonGoods)).
+		AddOp(txscript.OP_DROP).
+		AddData(bytes.Repeat([]byte{0x02}, 32)).
+		AddOp(txscript.OP_CHECKSIG).Script()
+
+	testCases := []struct {
+		testCase  string
+		msgTx     *wire.MsgTx
+		height    int32
+		err       error
+		inscribed bool
+	}{
+		{"etching committed in envelope", testRunestoneTx(envelope(uncommonGoods), etching(uncommonGoods)), 840000,
+			nil, true},
+		{"commitment outside envelope", testRunestoneTx(wire.TxWitness{withoutEnvelope, testControlBlock(0xc0, 0)},
+			etching(uncommonGoods)), 840000, nil, false},
+		{"etching without rune name", testRunestoneTx(nil, etching(nil)), 840000, nil, false},
+		{"etching without rune name before activation", testRunestoneTx(nil, etching(nil)), 0, runes.ErrRuneLocked,
+			false},
+		{"envelope commits to another rune", testRunestoneTx(envelope(big.NewInt(1)), etching(uncommonGoods)), 840000,
+			runes.ErrNoCommitment, false},
+		{"envelope without rune", testRunestoneTx(envelope(nil), etching(uncommonGoods)), 840000,
+			runes.ErrNoCommitment, false},
+		{"commitment in key path spend", testRunestoneTx(wire.TxWitness{runes.Commitment(uncommonGoods)},
+			etching(uncommonGoods)), 840000, runes.ErrNoCommitment, false},
+		{"rune not unlocked", testRunestoneTx(envelope(uncommonGoods), etching(uncommonGoods)), 839999,
+			runes.ErrRuneLocked, false},
+		{"short rune not unlocked", testRunestoneTx(envelope(testRune(t, "AAAA")), etching(testRune(t, "AAAA"))),
+			840000, runes.ErrRuneLocked, false},
+		{"reserved rune", testRunestoneTx(nil, etching(runes.ReservedRune(runes.RuneID{Block: 1}))), 840000,
+			runes.ErrReservedRune, false},
+		{"no etching", testRunestoneTx(envelope(uncommonGoods), testRunestoneScript()), 840000,
+			runes.ErrNoEtching, false},
+	}
+	for _, testCase := range testCases {
+		inscription, err := runes.ValidateEtching(testCase.msgTx, runes.Decipher(testCase.msgTx), testCase.height,
+			&chaincfg.MainNetParams)
+		if !errors.Is(err, testCase.err) || (inscription != nil) != testCase.inscribed {
+			t.Errorf("test %s failed, expected error %v, got inscription %v, error: %v", testCase.testCase,
+				testCase.err, inscription, err)
+			continue
+		}
+		if inscription != nil && inscription.Inscription.Rune.Cmp(uncommonGoods) != 0 {
+			t.Errorf("test %s failed, got inscription rune %v", testCase.testCase, inscription.Inscription.Rune)
+			continue
+		}
+		t.Logf("test %s: test passed", testCase.testCase)
+	}
+}
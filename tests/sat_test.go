@@ -0,0 +1,227 @@
+package parser
+
+import (
+	"errors"
+	"testing"
+
+	"github.com/balletcrypto/bitcoin-inscription-parser/parser"
+	"github.com/balletcrypto/bitcoin-inscription-parser/sat"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+func TestSatNotations(t *testing.T) {
+	t.Parallel()
+
+	const coin = 100000000
+	testCases := []struct {
+		sat     sat.Sat
+		height  int32
+		degree  string
+		decimal string
+		name    string
+		rarity  sat.Rarity
+	}{
+		{0, 0, "0°0′0″0‴", "0.0", "nvtdijuwxlp", sat.Mythic},
+		{1, 0, "0°0′0″1‴", "0.1", "nvtdijuwxlo", sat.Common},
+		{26, 0, "0°0′0″26‴", "0.26", "nvtdijuwxkp", sat.Common},
+		{27, 0, "0°0′0″27‴", "0.27", "nvtdijuwxko", sat.Common},
+		{50*coin - 1, 0, "0°0′0″4999999999‴", "0.4999999999", "", sat.Common},
+		{50 * coin, 1, "0°1′1″0‴", "1.0", "", sat.Uncommon},
+		{50*coin*2016 - 1, 2015, "0°2015′2015″4999999999‴", "2015.4999999999", "", sat.Common},
+		{50 * coin * 2016, 2016, "0°2016′0″0‴", "2016.0", "", sat.Rare},
+		{50*coin*210000 - 1, 209999, "0°209999′335″4999999999‴", "209999.4999999999", "", sat.Common},
+		{50 * coin * 210000, 210000, "0°0′336″0‴", "210000.0", "", sat.Epic},
+		{2067187500000000, 1260000, "1°0′0″0‴", "1260000.0", "", sat.Legendary},
+		{sat.LastSat, 6929999, "5°209999′1007″0‴", "6929999.0", "a", sat.Uncommon},
+		{sat.LastSat - 1, 6929998, "5°209998′1006″0‴", "6929998.0", "b", sat.Uncommon},
+		{sat.LastSat - 25, 6929974, "5°209974′982″0‴", "6929974.0", "z", sat.Uncommon},
+		{sat.LastSat - 26, 6929973, "5°209973′981″0‴", "6929973.0", "aa", sat.Uncommon},
+	}
+	for _, testCase := range testCases {
+		s := testCase.sat
+		if s.Height() != testCase.height || s.Degree().String() != testCase.degree ||
+			s.Decimal() != testCase.decimal || s.Rarity() != testCase.rarity {
+			t.Errorf("test sat %d failed, got height %d, degree %s, decimal %s, rarity %s", s, s.Height(), s.Degree(),
+				s.Decimal(), s.Rarity())
+		}
+		if testCase.name != "" && s.Name() != testCase.name {
+			t.Errorf("test sat %d failed, expected name %s, got %s", s, testCase.name, s.Name())
+		}
+		for _, notation := range []string{s.String(), testCase.degree, testCase.decimal, s.Name()} {
+			if parsed, err := sat.ParseSat(notation); err != nil || parsed != s {
+				t.Errorf("test sat %d failed, parse %q got %d, %v", s, notation, parsed, err)
+			}
+		}
+	}
+
+	for _, invalid := range []string{
+		"", "2099999997690000", "-1", "0.5000000000", "0.x", "6930000.0", "0°0′0″5000000000‴", "0°1′0″0‴",
+		"0°210000′0″0‴", "0°0′2016″0‴", "6°0′0″0‴", "0°0′", "nvtdijuwxlq", "A", "0°0′0″0‴0",
+	} {
+		if _, err := sat.ParseSat(invalid); !errors.Is(err, sat.ErrInvalidSat) {
+			t.Errorf("test parse sat failed, expected ErrInvalidSat for %q, got %v", invalid, err)
+		}
+	}
+	// The third is optional in degree notation
+	if s, err := sat.ParseSat("0°1′1″"); err != nil || s != 50*coin {
+		t.Errorf("test parse sat failed, got %d, %v", s, err)
+	}
+}
+
+func TestSatEpochs(t *testing.T) {
+	t.Parallel()
+
+	startingSats := []sat.Sat{0, 1050000000000000, 1575000000000000, 1837500000000000, 1968750000000000}
+	for epoch, startingSat := range startingSats {
+		if sat.Epoch(epoch).StartingSat() != startingSat {
+			t.Errorf("test epoch %d failed, expected starting sat %d, got %d", epoch, startingSat,
+				sat.Epoch(epoch).StartingSat())
+		}
+	}
+	if sat.FirstPostSubsidy.StartingSat() != sat.Sat(sat.Supply) || sat.Epoch(32).Subsidy() != 1 ||
+		sat.FirstPostSubsidy.Subsidy() != 0 {
+		t.Errorf("test epochs failed, the subsidy must end with the supply")
+	}
+	if r := sat.SubsidyRange(6930000); r.Size() != 0 || r.Start != sat.Sat(sat.Supply) {
+		t.Errorf("test epochs failed, expected an empty subsidy range after the last halving, got %s", r)
+	}
+}
+
+// TestSatBoundaries checks every halving and every difficulty adjustment until the subsidy ends.
+func TestSatBoundaries(t *testing.T) {
+	t.Parallel()
+
+	check := func(height int32) {
+		if height < 0 || sat.Subsidy(height) == 0 {
+			return
+		}
+		r := sat.SubsidyRange(height)
+		if r.Size() != sat.Subsidy(height) || sat.StartingSat(height+1) != r.End {
+			t.Errorf("test height %d failed, subsidy range %s does not end at the next block", height, r)
+			return
+		}
+		first, last := r.Start, r.End-1
+		if first.Height() != height || last.Height() != height || first.Third() != 0 ||
+			last.Third() != r.Size()-1 || first.Epoch() != sat.EpochAt(height) {
+			t.Errorf("test height %d failed, got heights %d and %d", height, first.Height(), last.Height())
+		}
+
+		expected := sat.Uncommon
+		switch {
+		case height == 0:
+			expected = sat.Mythic
+		case height%(int32(sat.CycleEpochs)*sat.SubsidyHalvingInterval) == 0:
+			expected = sat.Legendary
+		case height%sat.SubsidyHalvingInterval == 0:
+			expected = sat.Epic
+		case height%sat.DiffChangeInterval == 0:
+			expected = sat.Rare
+		}
+		if first.Rarity() != expected || (r.Size() > 1 && last.Rarity() != sat.Common) {
+			t.Errorf("test height %d failed, expected %s, got %s", height, expected, first.Rarity())
+		}
+		for _, s := range []sat.Sat{first, last} {
+			if parsed, err := sat.ParseSat(s.Degree().String()); err != nil || parsed != s {
+				t.Errorf("test height %d failed, degree %s parsed as %d, %v", height, s.Degree(), parsed, err)
+			}
+			if parsed, err := sat.ParseSat(s.Name()); err != nil || parsed != s {
+				t.Errorf("test height %d failed, name %s parsed as %d, %v", height, s.Name(), parsed, err)
+			}
+		}
+	}
+
+	for height := int32(0); sat.Subsidy(height) > 0; height += sat.DiffChangeInterval {
+		check(height - 1)
+		check(height)
+		check(height + 1)
+	}
+	for epoch := sat.Epoch(0); epoch < sat.FirstPostSubsidy; epoch++ {
+		check(epoch.StartingHeight() - 1)
+		check(epoch.StartingHeight())
+		check(epoch.StartingHeight() + 1)
+	}
+}
+
+func TestSatAssign(t *testing.T) {
+	t.Parallel()
+
+	r := func(start, end sat.Sat) sat.Range {
+		return sat.Range{Start: start, End: end}
+	}
+	inputs := []sat.Range{r(0, 10), r(100, 105), r(200, 200), r(300, 320)}
+	outputs, fee := sat.Assign(inputs, []uint64{4, 0, 10, 11})
+	expected := [][]sat.Range{{r(0, 4)}, nil, {r(4, 10), r(100, 104)}, {r(104, 105), r(300, 310)}}
+	for i := range expected {
+		if len(outputs[i]) != len(expected[i]) {
+			t.Errorf("test assign failed, output %d: expected %v, got %v", i, expected[i], outputs[i])
+			continue
+		}
+		for j := range expected[i] {
+			if outputs[i][j] != expected[i][j] {
+				t.Errorf("test assign failed, output %d: expected %v, got %v", i, expected[i], outputs[i])
+			}
+		}
+	}
+	if len(fee) != 1 || fee[0] != (sat.Range{Start: 310, End: 320}) {
+		t.Errorf("test assign failed, expected fee [310, 320), got %v", fee)
+	}
+	if inputs[3] != (sat.Range{Start: 300, End: 320}) {
+		t.Errorf("test assign failed, the inputs were modified")
+	}
+
+	// The coinbase gets the subsidy then the fees, whatever it does not claim is lost
+	outputs, lost := sat.Assign(append([]sat.Range{sat.SubsidyRange(1)}, fee...), []uint64{5000000005})
+	if sat.Size(outputs[0]) != 5000000005 || sat.Size(lost) != 5 || lost[0].Start != 315 {
+		t.Errorf("test assign failed, expected 5 lost sats from 315, got %v", lost)
+	}
+}
+
+func TestInscribedSat(t *testing.T) {
+	t.Parallel()
+
+	script, _ := testEnvelope(txscript.NewScriptBuilder()).Script()
+	scriptWithPointer, _ := txscript.NewScriptBuilder().
+		AddOps([]byte{txscript.OP_FALSE, txscript.OP_IF}).
+		AddData([]byte("ord")).
+		AddOp(txscript.OP_DATA_1).
+		AddOp(txscript.OP_DATA_2).
+		AddData([]byte{0x0f, 0x00}).
+		AddOp(txscript.OP_ENDIF).Script()
+
+	msgTx := testTransaction(script, scriptWithPointer, script)
+	msgTx.AddTxOut(wire.NewTxOut(20, nil))
+	inscriptions := parser.ParseInscriptionsFromTransaction(msgTx)
+	inputRanges := [][]sat.Range{
+		{{Start: 1000, End: 1010}},
+		{{Start: 500, End: 505}, {Start: 2000, End: 2005}},
+		nil,
+	}
+
+	expected := []struct {
+		sat sat.Sat
+		err error
+	}{
+		// The first sat of the first input
+		{1000, nil},
+		// The pointer, offset 15 into the inputs, overrides the input
+		{2000, nil},
+		// An input without sats
+		{0, sat.ErrUnbound},
+	}
+	for i, e := range expected {
+		s, err := sat.InscribedSat(msgTx, inscriptions[i], inputRanges)
+		if s != e.sat || !errors.Is(err, e.err) {
+			t.Errorf("test inscribed sat failed, inscription %d: expected %d, %v, got %d, %v", i, e.sat, e.err, s, err)
+		}
+	}
+	if _, err := sat.InscribedSat(msgTx, inscriptions[0], inputRanges[:2]); !errors.Is(err, sat.ErrInputRanges) {
+		t.Errorf("test inscribed sat failed, expected ErrInputRanges, got %v", err)
+	}
+
+	// A pointer beyond the outputs is ignored
+	msgTx.TxOut[0].Value = 15
+	if s, err := sat.InscribedSat(msgTx, inscriptions[1], inputRanges); err != nil || s != 500 {
+		t.Errorf("test inscribed sat failed, expected the first sat of the input, got %d, %v", s, err)
+	}
+}
@@ -1,6 +1,7 @@
 package parser
 
 import (
+	"errors"
 	"testing"
 
 	"github.com/balletcrypto/bitcoin-inscription-parser/parser"
@@ -245,12 +246,12 @@ func TestScriptWithDuplicatedTag(t *testing.T) {
 		{
 			testCase: "test script with duplicated content type tag",
 			script:   scriptWithDuplicatedContentTypeTag,
-			expected: false,
+			expected: true,
 		},
 		{
 			testCase: "test script with unknown duplicated tag",
 			script:   scriptWithUnknownDuplicatedTag,
-			expected: false,
+			expected: true,
 		},
 	}
 
@@ -262,7 +263,8 @@ func TestScriptWithDuplicatedTag(t *testing.T) {
 					inscriptions[i].ContentLength)
 			}
 		}
-		exist := len(inscriptions) != 0
+		// Duplicated tags no longer drop the inscription, they are flagged instead
+		exist := len(inscriptions) != 0 && inscriptions[0].IsDuplicateField
 		if exist == test.expected {
 			t.Logf("%s: test passed", test.testCase)
 		} else {
@@ -469,7 +471,7 @@ func TestScriptWithInvalidDtaLength(t *testing.T) {
 	}{
 		testCase: "test script with invalid data length",
 		script:   script,
-		expected: false,
+		expected: true,
 	}
 
 	inscriptions := parser.ParseInscriptions(test.script)
@@ -479,7 +481,9 @@ func TestScriptWithInvalidDtaLength(t *testing.T) {
 				inscriptions[i].ContentLength)
 		}
 	}
-	exist := len(inscriptions) != 0
+	// The misplaced OP_DATA_66 shifts the remaining pushes, leaving the last tag without a value. Like ord, the
+	// inscription is kept but flagged as having an incomplete field.
+	exist := len(inscriptions) != 0 && inscriptions[0].IsIncompleteField
 	if exist == test.expected {
 		t.Logf("%s: test passed", test.testCase)
 	} else {
@@ -613,3 +617,276 @@ func TestScriptWithNoEndIf(t *testing.T) {
 		}
 	}
 }
+
+func TestScriptWithEnvelopeFields(t *testing.T) {
+	parent := make([]byte, 33)
+	for i := range parent {
+		parent[i] = byte(i)
+	}
+	delegate := make([]byte, 32)
+	for i := range delegate {
+		delegate[i] = 0xff
+	}
+
+	script, _ := txscript.NewScriptBuilder().
+		AddOps([]byte{txscript.OP_FALSE, txscript.OP_IF}).
+		AddData([]byte("ord")).
+		AddOp(txscript.OP_DATA_1).
+		AddOp(txscript.OP_DATA_1).
+		AddData([]byte("text/plain;charset=utf-8")).
+		AddOp(txscript.OP_DATA_1).
+		AddOp(txscript.OP_DATA_2).
+		AddData([]byte{0x00, 0x01, 0x00}).
+		AddOp(txscript.OP_DATA_1).
+		AddOp(txscript.OP_DATA_3).
+		AddData(parent).
+		AddOp(txscript.OP_DATA_1).
+		AddOp(txscript.OP_DATA_5).
+		AddData([]byte{0xa1, 0x61, 0x61, 0x01}).
+		AddOp(txscript.OP_DATA_1).
+		AddOp(txscript.OP_DATA_7).
+		AddData([]byte("brc-20")).
+		AddOp(txscript.OP_DATA_1).
+		AddOp(txscript.OP_DATA_9).
+		AddData([]byte("br")).
+		AddOp(txscript.OP_DATA_1).
+		AddOp(txscript.OP_DATA_11).
+		AddData(delegate).
+		AddOp(txscript.OP_DATA_1).
+		AddOp(txscript.OP_DATA_13).
+		AddData([]byte{0x01, 0x02}).
+		AddOp(txscript.OP_0).
+		AddData([]byte("test script with envelope fields")).
+		AddOp(txscript.OP_ENDIF).Script()
+
+	inscriptions := parser.ParseInscriptions(script)
+	if len(inscriptions) != 1 {
+		t.Fatalf("test script with envelope fields: expected 1 inscription, got %d", len(inscriptions))
+	}
+	inscription := inscriptions[0]
+
+	if inscription.Pointer == nil || *inscription.Pointer != 256 {
+		t.Errorf("test script with envelope fields: unexpected pointer %v", inscription.Pointer)
+	}
+	if len(inscription.Parents) != 1 || inscription.Parents[0].TxID[31] != 31 || inscription.Parents[0].Index != 32 {
+		t.Errorf("test script with envelope fields: unexpected parents %v", inscription.Parents)
+	}
+	if string(inscription.RawMetadata) != string([]byte{0xa1, 0x61, 0x61, 0x01}) {
+		t.Errorf("test script with envelope fields: unexpected metadata %x", inscription.RawMetadata)
+	}
+	if string(inscription.Metaprotocol) != "brc-20" {
+		t.Errorf("test script with envelope fields: unexpected metaprotocol %s", inscription.Metaprotocol)
+	}
+	if string(inscription.ContentEncoding) != "br" {
+		t.Errorf("test script with envelope fields: unexpected content encoding %s", inscription.ContentEncoding)
+	}
+	if inscription.Delegate == nil || inscription.Delegate.TxID[0] != 0xff || inscription.Delegate.Index != 0 {
+		t.Errorf("test script with envelope fields: unexpected delegate %v", inscription.Delegate)
+	}
+	if inscription.Rune == nil || inscription.Rune.Int64() != 0x0201 {
+		t.Errorf("test script with envelope fields: unexpected rune %v", inscription.Rune)
+	}
+	if inscription.IsUnrecognizedEvenField {
+		t.Errorf("test script with envelope fields: pointer should not be an unrecognized even field")
+	}
+}
+
+func TestScriptWithInvalidEnvelopeFields(t *testing.T) {
+	script, _ := txscript.NewScriptBuilder().
+		AddOps([]byte{txscript.OP_FALSE, txscript.OP_IF}).
+		AddData([]byte("ord")).
+		AddOp(txscript.OP_DATA_1).
+		AddOp(txscript.OP_DATA_2).
+		AddData([]byte{0, 0, 0, 0, 0, 0, 0, 0, 1}).
+		AddOp(txscript.OP_DATA_1).
+		AddOp(txscript.OP_DATA_3).
+		AddData(make([]byte, 33)).
+		AddOp(txscript.OP_0).
+		AddData([]byte("test script with invalid envelope fields")).
+		AddOp(txscript.OP_ENDIF).Script()
+
+	inscriptions := parser.ParseInscriptions(script)
+	if len(inscriptions) != 1 {
+		t.Fatalf("test script with invalid envelope fields: expected 1 inscription, got %d", len(inscriptions))
+	}
+	if inscriptions[0].Pointer != nil {
+		t.Errorf("test script with invalid envelope fields: pointer should be nil")
+	}
+	if len(inscriptions[0].Parents) != 0 {
+		t.Errorf("test script with invalid envelope fields: parent with trailing zero index should be skipped")
+	}
+}
+
+func TestScriptWithChunkedFields(t *testing.T) {
+	parent1 := make([]byte, 32)
+	parent2 := make([]byte, 33)
+	parent2[32] = 1
+
+	script, _ := txscript.NewScriptBuilder().
+		AddOps([]byte{txscript.OP_FALSE, txscript.OP_IF}).
+		AddData([]byte("ord")).
+		AddOp(txscript.OP_DATA_1).
+		AddOp(txscript.OP_DATA_5).
+		AddData([]byte{0xa1, 0x61}).
+		AddOp(txscript.OP_DATA_1).
+		AddOp(txscript.OP_DATA_3).
+		AddData(parent1).
+		AddOp(txscript.OP_DATA_1).
+		AddOp(txscript.OP_DATA_5).
+		AddData([]byte{0x61, 0x01}).
+		AddOp(txscript.OP_DATA_1).
+		AddOp(txscript.OP_DATA_3).
+		AddData(parent2).
+		AddOp(txscript.OP_0).
+		AddData([]byte("test script with chunked fields")).
+		AddOp(txscript.OP_ENDIF).Script()
+
+	inscriptions := parser.ParseInscriptions(script)
+	if len(inscriptions) != 1 {
+		t.Fatalf("test script with chunked fields: expected 1 inscription, got %d", len(inscriptions))
+	}
+	inscription := inscriptions[0]
+	if string(inscription.RawMetadata) != string([]byte{0xa1, 0x61, 0x61, 0x01}) {
+		t.Errorf("test script with chunked fields: unexpected metadata %x", inscription.RawMetadata)
+	}
+	if len(inscription.Parents) != 2 || inscription.Parents[0].Index != 0 || inscription.Parents[1].Index != 1 {
+		t.Errorf("test script with chunked fields: unexpected parents %v", inscription.Parents)
+	}
+	if !inscription.IsDuplicateField {
+		t.Errorf("test script with chunked fields: repeated tags should be flagged as duplicate fields")
+	}
+}
+
+func TestScriptDiagnostics(t *testing.T) {
+	t.Parallel()
+
+	scriptWithNoEndIf, _ := txscript.NewScriptBuilder().
+		AddOps([]byte{txscript.OP_FALSE, txscript.OP_IF}).
+		AddData([]byte("ord")).
+		AddOp(txscript.OP_0).
+		AddData([]byte("test script with no END_IF")).
+		Script()
+
+	scriptWithOpcodeInBody, _ := txscript.NewScriptBuilder().
+		AddOps([]byte{txscript.OP_FALSE, txscript.OP_IF}).
+		AddData([]byte("ord")).
+		AddOp(txscript.OP_0).
+		AddOp(txscript.OP_CHECKSIG).
+		AddOp(txscript.OP_ENDIF).Script()
+
+	scriptWithInvalidPush, _ := txscript.NewScriptBuilder().
+		AddOps([]byte{txscript.OP_FALSE, txscript.OP_IF}).
+		AddData([]byte("ord")).
+		AddOp(txscript.OP_DATA_1).
+		AddOp(txscript.OP_DATA_1).
+		AddOp(txscript.OP_CHECKSIG).
+		AddOp(txscript.OP_ENDIF).Script()
+
+	// The script builder refuses pushes larger than 520 bytes, so the push is assembled by hand
+	largePush := append([]byte{txscript.OP_PUSHDATA2, 0x09, 0x02}, make([]byte, 521)...)
+	scriptWithLargePush := append([]byte{txscript.OP_FALSE, txscript.OP_IF, txscript.OP_DATA_3, 'o', 'r', 'd',
+		txscript.OP_0}, largePush...)
+	scriptWithLargePush = append(scriptWithLargePush, txscript.OP_ENDIF)
+
+	scriptWithTruncatedPush := []byte{txscript.OP_FALSE, txscript.OP_IF, txscript.OP_DATA_3, 'o', 'r', 'd',
+		txscript.OP_0, txscript.OP_DATA_2, 0x01}
+
+	tests := []struct {
+		testCase string
+		script   []byte
+		expected error
+		offset   int32
+	}{
+		{
+			testCase: "test script with no END_IF",
+			script:   scriptWithNoEndIf,
+			expected: parser.ErrMissingEndIf,
+			offset:   int32(len(scriptWithNoEndIf)),
+		},
+		{
+			testCase: "test script with opcode in body",
+			script:   scriptWithOpcodeInBody,
+			expected: parser.ErrOpcodeInBody,
+			offset:   7,
+		},
+		{
+			testCase: "test script with invalid push",
+			script:   scriptWithInvalidPush,
+			expected: parser.ErrInvalidPush,
+			offset:   8,
+		},
+		{
+			testCase: "test script with push larger than 520 bytes",
+			script:   scriptWithLargePush,
+			expected: parser.ErrPushTooLarge,
+			offset:   7,
+		},
+		{
+			testCase: "test script with truncated push",
+			script:   scriptWithTruncatedPush,
+			expected: parser.ErrMalformedScript,
+			offset:   7,
+		},
+	}
+
+	for _, test := range tests {
+		inscriptions, errs := parser.ParseInscriptionsWithDiagnostics(test.script)
+		if len(inscriptions) == 0 && len(errs) == 1 && errors.Is(errs[0], test.expected) && errs[0].Offset == test.offset {
+			t.Logf("%s: test passed", test.testCase)
+		} else {
+			t.Errorf("%s: test failed, inscriptions: %d, errors: %v", test.testCase, len(inscriptions), errs)
+		}
+	}
+}
+
+func TestScriptWithPushnum(t *testing.T) {
+	t.Parallel()
+
+	// The script builder converts []byte{1} into OP_1, which ord accepts as the content type tag
+	scriptWithPushnumTag, _ := txscript.NewScriptBuilder().
+		AddOps([]byte{txscript.OP_FALSE, txscript.OP_IF}).
+		AddData([]byte("ord")).
+		AddData([]byte{1}).
+		AddData([]byte("text/plain;charset=utf-8")).
+		AddOp(txscript.OP_0).
+		AddData([]byte("test script with pushnum")).
+		AddOp(txscript.OP_ENDIF).Script()
+
+	scriptWithPushnumBody, _ := txscript.NewScriptBuilder().
+		AddOps([]byte{txscript.OP_FALSE, txscript.OP_IF}).
+		AddData([]byte("ord")).
+		AddOp(txscript.OP_DATA_1).
+		AddOp(txscript.OP_DATA_1).
+		AddData([]byte("text/plain;charset=utf-8")).
+		AddOp(txscript.OP_0).
+		AddOps([]byte{txscript.OP_1NEGATE, txscript.OP_1, txscript.OP_16}).
+		AddOp(txscript.OP_ENDIF).Script()
+
+	tests := []struct {
+		testCase string
+		script   []byte
+		expected []byte
+	}{
+		{
+			testCase: "test script with pushnum tag",
+			script:   scriptWithPushnumTag,
+			expected: []byte("test script with pushnum"),
+		},
+		{
+			testCase: "test script with pushnum body",
+			script:   scriptWithPushnumBody,
+			expected: []byte{0x81, 0x01, 0x10},
+		},
+	}
+
+	for _, test := range tests {
+		inscriptions := parser.ParseInscriptions(test.script)
+		if len(inscriptions) == 1 && inscriptions[0].IsPushnum &&
+			string(inscriptions[0].ContentType) == "text/plain;charset=utf-8" &&
+			string(inscriptions[0].ContentBody) == string(test.expected) {
+			t.Logf("%s: test passed", test.testCase)
+		} else {
+			t.Errorf("%s: test failed", test.testCase)
+		}
+	}
+}
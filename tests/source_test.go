@@ -0,0 +1,245 @@
+package parser
+
+import (
+	"bytes"
+	"encoding/hex"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strconv"
+	"strings"
+	"testing"
+
+	"github.com/balletcrypto/bitcoin-inscription-parser/source"
+	"github.com/btcsuite/btcd/chaincfg"
+	"github.com/btcsuite/btcd/chaincfg/chainhash"
+	"github.com/btcsuite/btcd/rpcclient"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+// testChain holds the data served by every test source: a chain of three blocks, the last one with an inscription,
+// and an inscription transaction that is not in any block.
+type testChain struct {
+	blocks []*wire.MsgBlock
+	tx     *wire.MsgTx
+}
+
+func newTestChain() *testChain {
+	script, _ := testEnvelope(txscript.NewScriptBuilder()).Script()
+	genesis := chaincfg.RegressionNetParams.GenesisBlock
+	block1 := testChildBlock(genesis, 1)
+	block2 := testChildBlock(block1, 2, testTransaction(script))
+	tx := testTransaction(script, script)
+	tx.LockTime = 1
+	return &testChain{
+		blocks: []*wire.MsgBlock{genesis, block1, block2},
+		tx:     tx,
+	}
+}
+
+func (c *testChain) block(blockHash string) *wire.MsgBlock {
+	for _, msgBlock := range c.blocks {
+		if msgBlock.BlockHash().String() == blockHash {
+			return msgBlock
+		}
+	}
+	return nil
+}
+
+func (c *testChain) transaction(txID string) *wire.MsgTx {
+	if c.tx.TxHash().String() == txID {
+		return c.tx
+	}
+	for _, msgBlock := range c.blocks {
+		for _, msgTx := range msgBlock.Transactions {
+			if msgTx.TxHash().String() == txID {
+				return msgTx
+			}
+		}
+	}
+	return nil
+}
+
+func testHex(serialize func(w io.Writer) error) string {
+	var buf bytes.Buffer
+	_ = serialize(&buf)
+	return hex.EncodeToString(buf.Bytes())
+}
+
+func (c *testChain) writeFixtures(t *testing.T) string {
+	dir := t.TempDir()
+	files := map[string]string{
+		filepath.Join("tx", c.tx.TxHash().String()+".hex"): testHex(c.tx.Serialize),
+	}
+	for height, msgBlock := range c.blocks {
+		files[filepath.Join("block", strconv.Itoa(height)+".hex")] = testHex(msgBlock.Serialize)
+	}
+	for name, data := range files {
+		path := filepath.Join(dir, name)
+		if err := os.MkdirAll(filepath.Dir(path), 0o755); err != nil {
+			t.Fatalf("write fixtures failed, %v", err)
+		}
+		if err := os.WriteFile(path, []byte(data+"\n"), 0o600); err != nil {
+			t.Fatalf("write fixtures failed, %v", err)
+		}
+	}
+	return dir
+}
+
+// esploraHandler serves the subset of the Esplora API used by source.EsploraSource.
+func (c *testChain) esploraHandler(w http.ResponseWriter, r *http.Request) {
+	parts := strings.Split(strings.Trim(r.URL.Path, "/"), "/")
+	switch {
+	case len(parts) == 3 && parts[0] == "tx" && parts[2] == "hex":
+		if msgTx := c.transaction(parts[1]); msgTx != nil {
+			fmt.Fprint(w, testHex(msgTx.Serialize))
+			return
+		}
+	case len(parts) == 3 && parts[0] == "block" && parts[2] == "raw":
+		if msgBlock := c.block(parts[1]); msgBlock != nil {
+			_ = msgBlock.Serialize(w)
+			return
+		}
+	case len(parts) == 2 && parts[0] == "block-height":
+		if height, err := strconv.Atoi(parts[1]); err == nil && height >= 0 && height < len(c.blocks) {
+			fmt.Fprint(w, c.blocks[height].BlockHash().String())
+			return
+		}
+	case r.URL.Path == "/blocks/tip/height":
+		fmt.Fprint(w, len(c.blocks)-1)
+		return
+	}
+	http.Error(w, "Not found", http.StatusNotFound)
+}
+
+// rpcHandler serves the subset of the bitcoind JSON-RPC API used by source.RPCSource.
+func (c *testChain) rpcHandler(w http.ResponseWriter, r *http.Request) {
+	var request struct {
+		ID     json.RawMessage   `json:"id"`
+		Method string            `json:"method"`
+		Params []json.RawMessage `json:"params"`
+	}
+	if err := json.NewDecoder(r.Body).Decode(&request); err != nil {
+		http.Error(w, err.Error(), http.StatusBadRequest)
+		return
+	}
+
+	var (
+		result  interface{}
+		rpcCode int
+	)
+	var param string
+	if len(request.Params) > 0 {
+		_ = json.Unmarshal(request.Params[0], &param)
+	}
+	switch request.Method {
+	case "getrawtransaction":
+		if msgTx := c.transaction(param); msgTx != nil {
+			result = testHex(msgTx.Serialize)
+		} else {
+			rpcCode = -5
+		}
+	case "getblock":
+		if msgBlock := c.block(param); msgBlock != nil {
+			result = testHex(msgBlock.Serialize)
+		} else {
+			rpcCode = -5
+		}
+	case "getblockhash":
+		var height int
+		_ = json.Unmarshal(request.Params[0], &height)
+		if height >= 0 && height < len(c.blocks) {
+			result = c.blocks[height].BlockHash().String()
+		} else {
+			rpcCode = -8
+		}
+	case "getblockcount":
+		result = len(c.blocks) - 1
+	default:
+		rpcCode = -32601
+	}
+
+	response := map[string]interface{}{"id": request.ID, "result": result, "error": nil}
+	if rpcCode != 0 {
+		response["result"] = nil
+		response["error"] = map[string]interface{}{"code": rpcCode, "message": "test error"}
+	}
+	_ = json.NewEncoder(w).Encode(response)
+}
+
+func TestSources(t *testing.T) {
+	t.Parallel()
+
+	chain := newTestChain()
+
+	fixtureSource, err := source.NewFixtureSource(chain.writeFixtures(t))
+	if err != nil {
+		t.Fatalf("test sources failed, %v", err)
+	}
+
+	esploraServer := httptest.NewServer(http.HandlerFunc(chain.esploraHandler))
+	defer esploraServer.Close()
+
+	rpcServer := httptest.NewServer(http.HandlerFunc(chain.rpcHandler))
+	defer rpcServer.Close()
+	client, err := rpcclient.New(&rpcclient.ConnConfig{
+		Host:         strings.TrimPrefix(rpcServer.URL, "http://"),
+		User:         "user",
+		Pass:         "pass",
+		HTTPPostMode: true,
+		DisableTLS:   true,
+	}, nil)
+	if err != nil {
+		t.Fatalf("test sources failed, %v", err)
+	}
+	defer client.Shutdown()
+
+	sources := map[string]source.Source{
+		"fixture": fixtureSource,
+		"esplora": source.NewEsploraSource(esploraServer.URL+"/", nil),
+		"rpc":     source.NewRPCSource(client),
+	}
+	missing := chainhash.Hash{0x01}
+
+	for name, s := range sources {
+		bestHeight, err := s.BestHeight()
+		if err != nil || bestHeight != 2 {
+			t.Errorf("test %s source failed, best height %d, %v", name, bestHeight, err)
+		}
+
+		blockInscriptions, err := source.ParseBlock(s, bestHeight)
+		if err != nil {
+			t.Errorf("test %s source failed, %v", name, err)
+		} else if blockInscriptions.BlockHash != chain.blocks[2].BlockHash() ||
+			len(blockInscriptions.Transactions) != 1 {
+			t.Errorf("test %s source failed, unexpected block inscriptions", name)
+		}
+
+		txID := chain.tx.TxHash()
+		inscriptions, err := source.ParseTransaction(s, &txID)
+		if err != nil || len(inscriptions) != 2 {
+			t.Errorf("test %s source failed, expected 2 inscriptions, got %d, %v", name, len(inscriptions), err)
+		}
+
+		blockTxID := chain.blocks[2].Transactions[1].TxHash()
+		if msgTx, err := s.GetTransaction(&blockTxID); err != nil || msgTx.TxHash() != blockTxID {
+			t.Errorf("test %s source failed, block transaction not found, %v", name, err)
+		}
+
+		if _, err := s.GetTransaction(&missing); !errors.Is(err, source.ErrNotFound) {
+			t.Errorf("test %s source failed, expected ErrNotFound for missing transaction, got %v", name, err)
+		}
+		if _, err := s.GetBlock(&missing); !errors.Is(err, source.ErrNotFound) {
+			t.Errorf("test %s source failed, expected ErrNotFound for missing block, got %v", name, err)
+		}
+		if _, err := source.GetBlockByHeight(s, 3); !errors.Is(err, source.ErrNotFound) {
+			t.Errorf("test %s source failed, expected ErrNotFound for missing height, got %v", name, err)
+		}
+	}
+}
@@ -0,0 +1,251 @@
+package parser
+
+import (
+	"bytes"
+	"crypto/sha256"
+	"errors"
+	"io"
+	"reflect"
+	"testing"
+
+	"github.com/balletcrypto/bitcoin-inscription-parser/parser"
+	"github.com/btcsuite/btcd/txscript"
+)
+
+// testStreamInscriptions reads every envelope with a StreamParser, the way ParseInscriptionsWithDiagnostics would
+// report them.
+func testStreamInscriptions(script []byte) ([]*parser.InscriptionContent, []*parser.EnvelopeError, error) {
+	var (
+		inscriptions   []*parser.InscriptionContent
+		envelopeErrors []*parser.EnvelopeError
+	)
+	streamParser := parser.NewStreamParser(bytes.NewReader(script))
+	for {
+		streamed, err := streamParser.Next()
+		var envelopeErr *parser.EnvelopeError
+		if errors.As(err, &envelopeErr) {
+			envelopeErrors = append(envelopeErrors, envelopeErr)
+			continue
+		} else if err == io.EOF {
+			return inscriptions, envelopeErrors, nil
+		} else if err != nil {
+			return nil, nil, err
+		}
+		body, err := io.ReadAll(streamed.Body)
+		if errors.As(err, &envelopeErr) {
+			envelopeErrors = append(envelopeErrors, envelopeErr)
+			continue
+		} else if err != nil {
+			return nil, nil, err
+		}
+		if len(body) > 0 {
+			streamed.Inscription.ContentBody = body
+		}
+		inscriptions = append(inscriptions, streamed.Inscription)
+	}
+}
+
+func testEnvelopeHeader() *txscript.ScriptBuilder {
+	return txscript.NewScriptBuilder().
+		AddOps([]byte{txscript.OP_FALSE, txscript.OP_IF}).
+		AddData([]byte("ord"))
+}
+
+func TestStreamParserMatchesParseInscriptions(t *testing.T) {
+	t.Parallel()
+
+	largeBody, _ := testEnvelopeHeader().
+		AddData([]byte(parser.ContentTypeTag)).
+		AddData([]byte("image/png")).
+		AddOp(txscript.OP_0).
+		AddData(bytes.Repeat([]byte{0xab}, 520)).
+		AddData(bytes.Repeat([]byte{0xcd}, 100)).
+		AddOp(txscript.OP_ENDIF).Script()
+	envelopeFields, _ := parser.BuildInscriptionScript(nil, &parser.InscriptionContent{
+		ContentType:     []byte("text/plain"),
+		ContentEncoding: []byte("br"),
+		Metaprotocol:    []byte("brc-20"),
+		RawMetadata:     bytes.Repeat([]byte{0xa0}, 600),
+		ContentBody:     []byte("fields"),
+	})
+	stutter, _ := txscript.NewScriptBuilder().
+		AddOp(txscript.OP_FALSE).
+		AddOp(txscript.OP_FALSE).
+		AddOp(txscript.OP_IF).
+		AddData([]byte("ord")).
+		AddOp(txscript.OP_ENDIF).Script()
+	failedStutterInFields, _ := txscript.NewScriptBuilder().
+		AddOps([]byte{txscript.OP_FALSE, txscript.OP_FALSE, txscript.OP_IF}).
+		AddData([]byte("ord")).
+		AddOp(txscript.OP_CHECKSIG).
+		AddOp(txscript.OP_ENDIF).Script()
+	failedStutterInBody, _ := txscript.NewScriptBuilder().
+		AddOps([]byte{txscript.OP_FALSE, txscript.OP_FALSE, txscript.OP_IF}).
+		AddData([]byte("ord")).
+		AddOp(txscript.OP_0).
+		AddOp(txscript.OP_CHECKSIG).
+		AddOp(txscript.OP_ENDIF).Script()
+	pushnum, _ := testEnvelopeHeader().
+		AddOp(txscript.OP_0).
+		AddOp(txscript.OP_1NEGATE).
+		AddOp(txscript.OP_16).
+		AddOp(txscript.OP_ENDIF).Script()
+	incomplete, _ := testEnvelopeHeader().
+		AddData([]byte{0x01}).
+		AddData([]byte("text/plain")).
+		AddData([]byte{0x07}).
+		AddOp(txscript.OP_ENDIF).Script()
+	opcodeInBody, _ := testEnvelopeHeader().
+		AddOp(txscript.OP_0).
+		AddData([]byte("first")).
+		AddOp(txscript.OP_CHECKSIG).
+		AddOp(txscript.OP_ENDIF).Script()
+	invalidPush, _ := testEnvelopeHeader().
+		AddData([]byte{0x01}).
+		AddOp(txscript.OP_CHECKSIG).
+		AddOp(txscript.OP_ENDIF).Script()
+	missingEndIf, _ := testEnvelopeHeader().
+		AddOp(txscript.OP_0).
+		AddData([]byte("no end")).Script()
+	valid, _ := testEnvelopeHeader().
+		AddData([]byte{0x01}).
+		AddData([]byte("text/plain")).
+		AddOp(txscript.OP_0).
+		AddData([]byte("valid")).
+		AddOp(txscript.OP_ENDIF).Script()
+
+	tests := []struct {
+		testCase string
+		script   []byte
+	}{
+		{
+			testCase: "test stream with body split over several pushes",
+			script:   largeBody,
+		},
+		{
+			testCase: "test stream with every envelope field",
+			script:   envelopeFields,
+		},
+		{
+			testCase: "test stream with stutter",
+			script:   stutter,
+		},
+		{
+			testCase: "test stream with stutter failing in the fields followed by valid envelope",
+			script:   append(append([]byte{}, failedStutterInFields...), valid...),
+		},
+		{
+			testCase: "test stream with stutter failing in the body followed by valid envelope",
+			script:   append(append([]byte{}, failedStutterInBody...), valid...),
+		},
+		{
+			testCase: "test stream with pushnum body",
+			script:   pushnum,
+		},
+		{
+			testCase: "test stream with incomplete field",
+			script:   incomplete,
+		},
+		{
+			testCase: "test stream with opcode in body followed by valid envelope",
+			script:   append(append([]byte{}, opcodeInBody...), valid...),
+		},
+		{
+			testCase: "test stream with invalid push followed by valid envelope",
+			script:   append(append([]byte{}, invalidPush...), valid...),
+		},
+		{
+			testCase: "test stream with missing OP_ENDIF",
+			script:   append(append([]byte{}, valid...), missingEndIf...),
+		},
+		{
+			testCase: "test stream with truncated push in body",
+			script:   append(append([]byte{}, valid...), 0x00, 0x63, 0x03, 'o', 'r', 'd', 0x00, 0x4c, 0x10, 0x01),
+		},
+		{
+			testCase: "test stream with truncated push outside envelope",
+			script:   append(append([]byte{}, valid...), 0x4d, 0x10),
+		},
+	}
+
+	for _, test := range tests {
+		expected, expectedErrs := parser.ParseInscriptionsWithDiagnostics(test.script)
+		inscriptions, errs, err := testStreamInscriptions(test.script)
+		if err != nil {
+			t.Errorf("%s: test failed, %v", test.testCase, err)
+			continue
+		}
+		if !reflect.DeepEqual(inscriptions, expected) {
+			t.Errorf("%s: test failed, expected %v, got %v", test.testCase, expected, inscriptions)
+		}
+		if len(errs) != len(expectedErrs) {
+			t.Errorf("%s: test failed, expected %d errors, got %d", test.testCase, len(expectedErrs), len(errs))
+			continue
+		}
+		for i := range errs {
+			if !errors.Is(errs[i], expectedErrs[i].Err) || errs[i].Offset != expectedErrs[i].Offset {
+				t.Errorf("%s: test failed, expected %v, got %v", test.testCase, expectedErrs[i], errs[i])
+			}
+		}
+	}
+}
+
+func TestStreamParserLargeBody(t *testing.T) {
+	t.Parallel()
+
+	body := make([]byte, 4<<20-1000)
+	for i := range body {
+		body[i] = byte(i * 7)
+	}
+	script, err := parser.BuildInscriptionScript(nil, &parser.InscriptionContent{
+		ContentType: []byte("application/octet-stream"),
+		ContentBody: body,
+	})
+	if err != nil {
+		t.Fatalf("test stream with large body failed, %v", err)
+	}
+
+	streamParser := parser.NewStreamParser(bytes.NewReader(script))
+	streamed, err := streamParser.Next()
+	if err != nil {
+		t.Fatalf("test stream with large body failed, %v", err)
+	}
+	if string(streamed.Inscription.ContentType) != "application/octet-stream" {
+		t.Errorf("test stream with large body failed, unexpected content type %q", streamed.Inscription.ContentType)
+	}
+	hash := sha256.New()
+	// A small buffer shows the body never has to be held in memory
+	if _, err := io.CopyBuffer(hash, streamed.Body, make([]byte, 100)); err != nil {
+		t.Fatalf("test stream with large body failed, %v", err)
+	}
+	if expected := sha256.Sum256(body); !bytes.Equal(hash.Sum(nil), expected[:]) {
+		t.Errorf("test stream with large body failed, body does not match")
+	}
+	if streamed.Inscription.ContentLength != uint64(len(body)) {
+		t.Errorf("test stream with large body failed, expected length %d, got %d", len(body),
+			streamed.Inscription.ContentLength)
+	}
+	if _, err := streamParser.Next(); err != io.EOF {
+		t.Errorf("test stream with large body failed, expected io.EOF, got %v", err)
+	}
+}
+
+func TestStreamParserSkipsUnreadBody(t *testing.T) {
+	t.Parallel()
+
+	first := &parser.InscriptionContent{ContentBody: bytes.Repeat([]byte{0x01}, 2000)}
+	second := &parser.InscriptionContent{ContentType: []byte("text/plain"), ContentBody: []byte("second")}
+	script, _ := parser.BuildInscriptionScript(nil, first, second)
+
+	streamParser := parser.NewStreamParser(bytes.NewReader(script))
+	if _, err := streamParser.Next(); err != nil {
+		t.Fatalf("test skip unread body failed, %v", err)
+	}
+	streamed, err := streamParser.Next()
+	if err != nil {
+		t.Fatalf("test skip unread body failed, %v", err)
+	}
+	if body, _ := io.ReadAll(streamed.Body); string(body) != "second" {
+		t.Errorf("test skip unread body failed, expected second body, got %q", body)
+	}
+}
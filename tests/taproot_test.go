@@ -0,0 +1,188 @@
+package parser
+
+import (
+	"bytes"
+	"errors"
+	"testing"
+
+	"github.com/balletcrypto/bitcoin-inscription-parser/parser"
+	"github.com/btcsuite/btcd/txscript"
+	"github.com/btcsuite/btcd/wire"
+)
+
+func testControlBlock(leafVersion byte, depth int) []byte {
+	// The x coordinate of the secp256k1 generator point is a valid internal key
+	internalKey := []byte{
+		0x79, 0xbe, 0x66, 0x7e, 0xf9, 0xdc, 0xbb, 0xac, 0x55, 0xa0, 0x62, 0x95, 0xce, 0x87, 0x0b, 0x07,
+		0x02, 0x9b, 0xfc, 0xdb, 0x2d, 0xce, 0x28, 0xd9, 0x59, 0xf2, 0x81, 0x5b, 0x16, 0xf8, 0x17, 0x98,
+	}
+	controlBlock := append([]byte{leafVersion}, internalKey...)
+	return append(controlBlock, bytes.Repeat([]byte{0xab}, 32*depth)...)
+}
+
+func testEnvelopeScript() []byte {
+	script, _ := txscript.NewScriptBuilder().
+		AddData(bytes.Repeat([]byte{0x02}, 32)).
+		AddOp(txscript.OP_CHECKSIG).
+		AddOps([]byte{txscript.OP_FALSE, txscript.OP_IF}).
+		AddData([]byte("ord")).
+		AddOp(txscript.OP_DATA_1).
+		AddOp(txscript.OP_DATA_1).
+		AddData([]byte("text/plain;charset=utf-8")).
+		AddOp(txscript.OP_0).
+		AddData([]byte("test tapscript extraction")).
+		AddOp(txscript.OP_ENDIF).Script()
+	return script
+}
+
+func TestExtractTapscript(t *testing.T) {
+	t.Parallel()
+
+	script := testEnvelopeScript()
+	signature := make([]byte, 64)
+	annex := []byte{txscript.TaprootAnnexTag, 0x01, 0x02}
+
+	tests := []struct {
+		testCase string
+		witness  wire.TxWitness
+		expected error
+		annex    bool
+	}{
+		{
+			testCase: "test script-path spend",
+			witness:  wire.TxWitness{signature, script, testControlBlock(0xc0, 0)},
+		},
+		{
+			testCase: "test script-path spend with odd y and merkle path",
+			witness:  wire.TxWitness{signature, script, testControlBlock(0xc1, 2)},
+		},
+		{
+			testCase: "test script-path spend with annex",
+			witness:  wire.TxWitness{signature, script, testControlBlock(0xc0, 1), annex},
+			annex:    true,
+		},
+		{
+			testCase: "test key-path spend",
+			witness:  wire.TxWitness{signature},
+			expected: parser.ErrNotScriptPathSpend,
+		},
+		{
+			testCase: "test key-path spend with annex",
+			witness:  wire.TxWitness{signature, annex},
+			expected: parser.ErrNotScriptPathSpend,
+		},
+		{
+			testCase: "test control block with invalid length",
+			witness:  wire.TxWitness{signature, script, testControlBlock(0xc0, 1)[:60]},
+			expected: parser.ErrInvalidControlBlock,
+		},
+		{
+			testCase: "test segwit v0 witness script",
+			witness:  wire.TxWitness{signature, script},
+			expected: parser.ErrInvalidControlBlock,
+		},
+		{
+			testCase: "test empty witness elements",
+			witness:  wire.TxWitness{{}, {}},
+			expected: parser.ErrInvalidControlBlock,
+		},
+		{
+			testCase: "test control block with unknown leaf version",
+			witness:  wire.TxWitness{signature, script, testControlBlock(0xc2, 0)},
+			expected: parser.ErrInvalidControlBlock,
+		},
+		{
+			testCase: "test control block with merkle path too long",
+			witness:  wire.TxWitness{signature, script, testControlBlock(0xc0, 129)},
+			expected: parser.ErrInvalidControlBlock,
+		},
+		{
+			testCase: "test control block with longest merkle path",
+			witness:  wire.TxWitness{signature, script, testControlBlock(0xc0, 128)},
+		},
+	}
+
+	for _, test := range tests {
+		tapscript, err := parser.ExtractTapscript(test.witness)
+		switch {
+		case test.expected != nil && errors.Is(err, test.expected):
+			t.Logf("%s: test passed", test.testCase)
+		case test.expected == nil && err == nil && bytes.Equal(tapscript.Script, script) &&
+			tapscript.LeafVersion == txscript.BaseLeafVersion && (tapscript.Annex != nil) == test.annex:
+			t.Logf("%s: test passed", test.testCase)
+		default:
+			t.Errorf("%s: test failed, error: %v", test.testCase, err)
+		}
+	}
+}
+
+func TestTapscriptControlBlock(t *testing.T) {
+	t.Parallel()
+
+	tapscript, err := parser.ExtractTapscript(wire.TxWitness{testEnvelopeScript(), testControlBlock(0xc1, 1)})
+	if err != nil {
+		t.Fatalf("test extract tapscript failed, error: %v", err)
+	}
+	controlBlock, err := tapscript.ParseControlBlock()
+	if err != nil || controlBlock.LeafVersion != txscript.BaseLeafVersion || !controlBlock.OutputKeyYIsOdd ||
+		len(controlBlock.InclusionProof) != 32 {
+		t.Errorf("test parse control block failed, got %+v, error: %v", controlBlock, err)
+	}
+
+	// The internal key is only decoded on demand, x = 0 is not on the curve
+	invalidKey := append([]byte{0xc0}, make([]byte, 32)...)
+	tapscript, err = parser.ExtractTapscript(wire.TxWitness{testEnvelopeScript(), invalidKey})
+	if err != nil {
+		t.Fatalf("test extract tapscript with invalid internal key failed, error: %v", err)
+	}
+	if _, err := tapscript.ParseControlBlock(); !errors.Is(err, parser.ErrInvalidControlBlock) {
+		t.Errorf("test parse control block with invalid internal key failed, error: %v", err)
+	}
+}
+
+func TestParseInscriptionsFromTransactionWithAnnex(t *testing.T) {
+	t.Parallel()
+
+	script := testEnvelopeScript()
+	signature := make([]byte, 64)
+	annex := []byte{txscript.TaprootAnnexTag}
+
+	tests := []struct {
+		testCase string
+		witness  wire.TxWitness
+		expected int
+	}{
+		{
+			testCase: "test inscription with annex",
+			witness:  wire.TxWitness{signature, script, testControlBlock(0xc0, 0), annex},
+			expected: 1,
+		},
+		{
+			// The annex itself must never be parsed as the script
+			testCase: "test envelope in annex position",
+			witness:  wire.TxWitness{signature, testControlBlock(0xc0, 0), append(annex, script...)},
+			expected: 0,
+		},
+		{
+			testCase: "test inscription with unknown leaf version",
+			witness:  wire.TxWitness{signature, script, testControlBlock(0xc2, 0)},
+			expected: 0,
+		},
+		{
+			testCase: "test envelope in segwit v0 witness script",
+			witness:  wire.TxWitness{signature, script},
+			expected: 0,
+		},
+	}
+
+	for _, test := range tests {
+		msgTx := wire.NewMsgTx(wire.TxVersion)
+		msgTx.AddTxIn(wire.NewTxIn(&wire.OutPoint{}, nil, test.witness))
+		inscriptions := parser.ParseInscriptionsFromTransaction(msgTx)
+		if len(inscriptions) == test.expected {
+			t.Logf("%s: test passed", test.testCase)
+		} else {
+			t.Errorf("%s: test failed, found %d inscriptions", test.testCase, len(inscriptions))
+		}
+	}
+}